@@ -1,43 +1,59 @@
-package main
-
-import (
-	"fmt"
-	"sort"
-	"strings"
-)
-
-func sortString(w string) string {
-	s := strings.Split(w, "")
-	sort.Strings(s)
-	return strings.Join(s, "")
-}
-func findAnagrams(words []string) map[string][]string {
-	anagramSets := make(map[string][]string)
-	for _, word := range words {
-		sortedWord := sortString(strings.ToLower(word))
-		anagramSets[sortedWord] = append(anagramSets[sortedWord], strings.ToLower(word))
-	}
-	result := make(map[string][]string)
-	for _, value := range anagramSets {
-		if len(value) > 1 {
-			key := value[0]
-			sort.Strings(value)
-			result[key] = value
-		}
-	}
-
-	return result
-}
-
-func main() {
-	list := []string{
-		"столик",
-		"пятак",
-		"тяпка",
-		"алгоритм",
-		"листок",
-		"пятка",
-		"слиток",
-	}
-	fmt.Println(findAnagrams(list))
-}
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+func findAnagrams(words []string, cfg foldConfig) map[string][]string {
+	anagramSets := make(map[string][]string)
+	for _, word := range words {
+		key := cfg.key(word)
+		anagramSets[key] = append(anagramSets[key], word)
+	}
+	result := make(map[string][]string)
+	for _, value := range anagramSets {
+		if len(value) > 1 {
+			key := value[0]
+			sort.Strings(value)
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+func main() {
+	list := []string{
+		"столик",
+		"пятак",
+		"тяпка",
+		"алгоритм",
+		"листок",
+		"пятка",
+		"слиток",
+	}
+	fmt.Println(findAnagrams(list, foldConfig{}))
+
+	fmt.Println(findAnagrams([]string{"Тёрка", "карте", "dormitory", "dirty room", "Café", "face"}, foldConfig{
+		stripDiacritics:  true,
+		ignoreNonLetters: true,
+		equivalences:     russianEquivalences,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dict := []string{"dirty", "room", "dormitory", "dorm", "it", "or", "try", "rod", "my", "tide"}
+	cfg := phraseConfig{
+		maxWords:   3,
+		minWordLen: 2,
+	}
+
+	for words := range findPhraseAnagrams(ctx, "dormitory", dict, cfg) {
+		fmt.Println(strings.Join(words, " "))
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestKey(t *testing.T) {
+	russian := foldConfig{equivalences: russianEquivalences}
+	strip := foldConfig{stripDiacritics: true}
+	letters := foldConfig{ignoreNonLetters: true}
+	nfd := foldConfig{form: norm.NFD}
+
+	tests := []struct {
+		name string
+		cfg  foldConfig
+		a, b string
+		same bool
+	}{
+		{"ё precomposed", russian, "тёрка", "карте", true},
+		{"ё decomposed", russian, "те\u0308рка", "карте", true},
+		{"ё without equivalences", foldConfig{}, "тёрка", "карте", false},
+		{"ё composed and decomposed", foldConfig{}, "тёрка", "те\u0308рка", true},
+		{"case", foldConfig{}, "Пятак", "тяпка", true},
+		{"stripped diacritics", strip, "Café", "face", true},
+		{"decomposed stripped diacritics", strip, "Cafe\u0301", "face", true},
+		{"kept diacritics", foldConfig{}, "Café", "face", false},
+		{"ignored spaces", letters, "dormitory", "dirty room", true},
+		{"ignored punctuation", letters, "dormitory", "dirty-room!", true},
+		{"spaces count", foldConfig{}, "dormitory", "dirty room", false},
+		{"NFD marks stay with their letter", nfd, "e\u0301a", "a\u0301e", false},
+		{"NFD clusters move together", nfd, "\u00e9a", "ae\u0301", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ka, kb := tt.cfg.key(tt.a), tt.cfg.key(tt.b)
+			if (ka == kb) != tt.same {
+				t.Fatalf("key(%q) = %q, key(%q) = %q; want same %v", tt.a, ka, tt.b, kb, tt.same)
+			}
+		})
+	}
+}
+
+func TestKeyForm(t *testing.T) {
+	if got := (foldConfig{form: norm.NFD}).key("\u00e9"); got != "e\u0301" {
+		t.Fatalf("NFD key = %q", got)
+	}
+	if got := (foldConfig{}).key("e\u0301"); got != "\u00e9" {
+		t.Fatalf("NFC key = %q", got)
+	}
+}
+
+func TestFindAnagrams(t *testing.T) {
+	got := findAnagrams([]string{"столик", "пятак", "тяпка", "алгоритм", "листок", "пятка", "слиток"}, foldConfig{})
+	want := map[string][]string{
+		"столик": {"листок", "слиток", "столик"},
+		"пятак":  {"пятак", "пятка", "тяпка"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+var phraseDict = []string{"dirty", "room", "dormitory", "dorm", "it", "or", "try", "rod", "my", "tide"}
+
+func collectPhrases(t *testing.T, ch <-chan []string) []string {
+	t.Helper()
+
+	var out []string
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case words, ok := <-ch:
+			if !ok {
+				sort.Strings(out)
+				return out
+			}
+			out = append(out, strings.Join(words, " "))
+		case <-timeout:
+			t.Fatal("phrase search did not finish")
+		}
+	}
+}
+
+func TestFindPhraseAnagrams(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  phraseConfig
+		want []string
+	}{
+		{"one word", phraseConfig{maxWords: 1}, []string{"dormitory"}},
+		{"up to three words", phraseConfig{maxWords: 3}, []string{"dirty room", "dormitory"}},
+		{"unlimited", phraseConfig{}, []string{"dirty room", "dormitory", "it my or rod"}},
+		{"long words only", phraseConfig{minWordLen: 3}, []string{"dirty room", "dormitory"}},
+		{"longer than the phrase", phraseConfig{minWordLen: 10}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collectPhrases(t, findPhraseAnagrams(context.Background(), "dormitory", phraseDict, tt.cfg))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPhraseAnagramsCancel(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	// Single letters make the search space huge, so it cannot finish on its own
+	// within the test.
+	var dict []string
+	for r := 'a'; r <= 'z'; r++ {
+		dict = append(dict, string(r), string(r)+string(r))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := findPhraseAnagrams(ctx, strings.Repeat("abcdefghij", 4), dict, phraseConfig{})
+	if _, ok := <-out; !ok {
+		t.Fatal("no result before cancel")
+	}
+	cancel()
+	collectPhrases(t, out)
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d running, want %d", runtime.NumGoroutine(), base)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
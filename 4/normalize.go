@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldConfig describes how a word is reduced before its anagram key is built.
+// The zero value lowercases and composes to NFC.
+type foldConfig struct {
+	form             norm.Form
+	stripDiacritics  bool
+	ignoreNonLetters bool
+	equivalences     map[rune]rune
+}
+
+var russianEquivalences = map[rune]rune{
+	'ё': 'е',
+}
+
+// fold composes word to NFC before anything else, so that decomposed input
+// such as е + U+0308 still hits the equivalences; form only shapes the
+// result.
+func (f foldConfig) fold(word string) string {
+	word = strings.ToLower(norm.NFC.String(word))
+
+	if len(f.equivalences) > 0 {
+		word = strings.Map(func(r rune) rune {
+			if to, ok := f.equivalences[r]; ok {
+				return to
+			}
+			return r
+		}, word)
+	}
+
+	if f.stripDiacritics {
+		word = strings.Map(func(r rune) rune {
+			if unicode.Is(unicode.Mn, r) {
+				return -1
+			}
+			return r
+		}, norm.NFD.String(word))
+	}
+
+	word = f.form.String(word)
+
+	if f.ignoreNonLetters {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+				return -1
+			}
+			return r
+		}, word)
+	}
+
+	return word
+}
+
+// key sorts the grapheme clusters of the folded word, so combining marks
+// stay with their base letter under NFD.
+func (f foldConfig) key(word string) string {
+	c := clusters(f.fold(word))
+	sort.Strings(c)
+	return strings.Join(c, "")
+}
+
+// clusters splits s into base runes each followed by its combining marks.
+func clusters(s string) []string {
+	var out []string
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) && len(out) > 0 {
+			out[len(out)-1] += string(r)
+			continue
+		}
+		out = append(out, string(r))
+	}
+
+	return out
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+type phraseConfig struct {
+	maxWords   int
+	minWordLen int
+	fold       foldConfig
+}
+
+type candidate struct {
+	word   string
+	counts []int
+	size   int
+}
+
+type phraseSolver struct {
+	cfg        phraseConfig
+	candidates []candidate
+	maxLen     int
+	out        chan<- []string
+}
+
+// findPhraseAnagrams streams every multiset of dictionary words whose letters
+// together are exactly the letters of phrase. Words inside one result keep
+// dictionary order, so permutations of the same words are reported once.
+// The channel is closed when the search is over or ctx is cancelled.
+func findPhraseAnagrams(ctx context.Context, phrase string, dict []string, cfg phraseConfig) <-chan []string {
+	out := make(chan []string)
+
+	alphabet := make(map[string]int)
+	var total []int
+	for _, l := range clusters(cfg.fold.fold(phrase)) {
+		if !isLetter(l) {
+			continue
+		}
+		idx, ok := alphabet[l]
+		if !ok {
+			idx = len(total)
+			alphabet[l] = idx
+			total = append(total, 0)
+		}
+		total[idx]++
+	}
+
+	s := &phraseSolver{
+		cfg: cfg,
+		out: out,
+	}
+
+	seen := make(map[string]struct{})
+	for _, word := range dict {
+		folded := cfg.fold.fold(word)
+		if _, ok := seen[folded]; ok {
+			continue
+		}
+		seen[folded] = struct{}{}
+
+		c, ok := newCandidate(word, folded, alphabet, total)
+		if !ok || c.size < cfg.minWordLen {
+			continue
+		}
+		if c.size > s.maxLen {
+			s.maxLen = c.size
+		}
+		s.candidates = append(s.candidates, c)
+	}
+
+	sort.Slice(s.candidates, func(i, j int) bool {
+		return s.candidates[i].word < s.candidates[j].word
+	})
+
+	go func() {
+		defer close(out)
+		remaining := 0
+		for _, n := range total {
+			remaining += n
+		}
+		if remaining == 0 {
+			return
+		}
+		s.search(ctx, total, remaining, 0, nil)
+	}()
+
+	return out
+}
+
+func newCandidate(word, folded string, alphabet map[string]int, total []int) (candidate, bool) {
+	c := candidate{
+		word:   word,
+		counts: make([]int, len(total)),
+	}
+
+	for _, l := range clusters(folded) {
+		if !isLetter(l) {
+			continue
+		}
+		idx, ok := alphabet[l]
+		if !ok {
+			return candidate{}, false
+		}
+		c.counts[idx]++
+		if c.counts[idx] > total[idx] {
+			return candidate{}, false
+		}
+		c.size++
+	}
+
+	return c, c.size > 0
+}
+
+// isLetter reports whether the grapheme cluster l starts with a letter.
+func isLetter(l string) bool {
+	r, _ := utf8.DecodeRuneInString(l)
+	return unicode.IsLetter(r)
+}
+
+// search returns false once the consumer is gone and the whole search must stop.
+func (s *phraseSolver) search(ctx context.Context, left []int, remaining, from int, words []string) bool {
+	if remaining == 0 {
+		result := make([]string, len(words))
+		copy(result, words)
+		select {
+		case s.out <- result:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if remaining < s.cfg.minWordLen {
+		return true
+	}
+
+	if s.cfg.maxWords > 0 {
+		slots := s.cfg.maxWords - len(words)
+		if slots <= 0 || remaining > slots*s.maxLen {
+			return true
+		}
+	}
+
+	for i := from; i < len(s.candidates); i++ {
+		c := s.candidates[i]
+		if c.size > remaining || !fits(c.counts, left) {
+			continue
+		}
+
+		for idx, n := range c.counts {
+			left[idx] -= n
+		}
+		ok := s.search(ctx, left, remaining-c.size, i, append(words, c.word))
+		for idx, n := range c.counts {
+			left[idx] += n
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func fits(counts, left []int) bool {
+	for idx, n := range counts {
+		if n > left[idx] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const defaultGrepColors = "ms=01;31:mc=01;31:sl=:cx=:fn=35:ln=32:bn=32:se=36"
+
+// colors holds SGR sequences under the GREP_COLORS capability names.
+type colors struct {
+	enabled       bool
+	selectedMatch string
+	contextMatch  string
+	selectedLine  string
+	contextLine   string
+	fileName      string
+	lineNum       string
+	byteOffset    string
+	separator     string
+	noErase       bool
+}
+
+func newColors(mode, spec string) (colors, error) {
+	switch mode {
+	case "never":
+		return colors{}, nil
+	case "auto":
+		if !isTerminal(os.Stdout) || os.Getenv("TERM") == "dumb" {
+			return colors{}, nil
+		}
+	case "always":
+	default:
+		return colors{}, fmt.Errorf("invalid --color argument %q", mode)
+	}
+
+	c := colors{
+		enabled: true,
+	}
+	c.parse(defaultGrepColors)
+	c.parse(spec)
+
+	return c, nil
+}
+
+func (c *colors) parse(spec string) {
+	for _, capability := range strings.Split(spec, ":") {
+		name, value, _ := strings.Cut(capability, "=")
+
+		switch name {
+		case "mt":
+			c.selectedMatch = value
+			c.contextMatch = value
+		case "ms":
+			c.selectedMatch = value
+		case "mc":
+			c.contextMatch = value
+		case "sl":
+			c.selectedLine = value
+		case "cx":
+			c.contextLine = value
+		case "fn":
+			c.fileName = value
+		case "ln":
+			c.lineNum = value
+		case "bn":
+			c.byteOffset = value
+		case "se":
+			c.separator = value
+		case "ne":
+			c.noErase = true
+		}
+	}
+}
+
+func (c *colors) paint(w *bufio.Writer, sgr, text string) {
+	if !c.enabled || sgr == "" || text == "" {
+		w.WriteString(text)
+		return
+	}
+
+	erase := "\x1b[K"
+	if c.noErase {
+		erase = ""
+	}
+
+	w.WriteString("\x1b[" + sgr + "m" + erase)
+	w.WriteString(text)
+	w.WriteString("\x1b[m" + erase)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,266 @@
+// Package grep implements the line matching engine behind the grep command:
+// pattern syntaxes, inversion, context, match limits, binary detection and
+// transparent decompression, without any output formatting.
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// BinaryMode controls how input that looks binary is handled.
+type BinaryMode int
+
+const (
+	// BinaryReport stops at the first selected line of a binary input and
+	// reports it in Result.Binary without delivering any lines.
+	BinaryReport BinaryMode = iota
+	// BinaryText searches binary input as if it were text (-a).
+	BinaryText
+	// BinarySkip does not search binary input at all (-I).
+	BinarySkip
+)
+
+const binaryPeekSize = 8 << 10
+
+// ErrStop may be returned by the callback passed to Search to end the search
+// early without an error.
+var ErrStop = errors.New("grep: stop")
+
+// Options mirrors the matching flags of the grep command.
+type Options struct {
+	// Patterns are alternatives; with none nothing is selected, as with
+	// grep -f /dev/null.
+	Patterns   []string
+	Syntax     Syntax
+	IgnoreCase bool
+	// SmartCase ignores case when no pattern contains an upper-case letter.
+	SmartCase bool
+	WordMatch bool
+	LineMatch bool
+	Invert    bool
+
+	Before int
+	After  int
+	// MaxCount stops the search after that many selected lines; zero means
+	// no limit. Trailing context of the last selected line is still reported.
+	MaxCount int
+
+	Binary     BinaryMode
+	Decompress bool
+	// Spans fills Match.Spans for every reported line.
+	Spans bool
+}
+
+// Match is a line reported by Search: either a selected line or, when
+// Context is set, a line of -A/-B context around one.
+type Match struct {
+	LineNumber int
+	// Offset is the byte offset of the line start in the (decoded) input.
+	Offset  int
+	Line    string
+	Context bool
+	// Spans holds the byte spans of matches within Line when Options.Spans
+	// is set. Lines selected by Invert have none.
+	Spans [][]int
+}
+
+// Result summarises one search.
+type Result struct {
+	Selected int
+	Bytes    int
+	Binary   bool
+}
+
+// Searcher runs searches with compiled options. It is safe for concurrent
+// use.
+type Searcher struct {
+	opts    Options
+	matcher *Matcher
+}
+
+// NewSearcher compiles the patterns of opts.
+func NewSearcher(opts Options) (*Searcher, error) {
+	m, err := newMatcher(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Searcher{
+		opts:    opts,
+		matcher: m,
+	}, nil
+}
+
+// Matcher returns the compiled patterns.
+func (s *Searcher) Matcher() *Matcher {
+	return s.matcher
+}
+
+// Search compiles opts and searches r, calling fn for every selected and
+// context line in input order.
+func Search(ctx context.Context, r io.Reader, opts Options, fn func(Match) error) (Result, error) {
+	s, err := NewSearcher(opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return s.Search(ctx, r, fn)
+}
+
+// Search streams r line by line, calling fn for every selected and context
+// line in input order. Only the -B window is kept in memory. If fn returns
+// ErrStop the search ends and Search returns a nil error.
+func (s *Searcher) Search(ctx context.Context, r io.Reader, fn func(Match) error) (Result, error) {
+	opts := s.opts
+	var res Result
+
+	if opts.Decompress {
+		zr, err := decompress(r)
+		if err != nil {
+			return res, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	reader := bufio.NewReaderSize(r, binaryPeekSize)
+
+	if opts.Binary != BinaryText {
+		head, err := reader.Peek(binaryPeekSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return res, err
+		}
+		res.Binary = bytes.IndexByte(head, 0) >= 0
+	}
+
+	if res.Binary && opts.Binary == BinarySkip {
+		return res, nil
+	}
+
+	emit := func(m Match) error {
+		if res.Binary {
+			return nil
+		}
+		if opts.Spans {
+			m.Spans = s.matcher.Spans(m.Line)
+		}
+		return fn(m)
+	}
+
+	before := newRing(opts.Before)
+	limited := opts.MaxCount > 0
+	afterLeft := 0
+	done := ctx.Done()
+
+	for num := 1; ; num++ {
+		// After MaxCount selected lines only the trailing context is left.
+		if limited && res.Selected >= opts.MaxCount && (afterLeft == 0 || res.Binary) {
+			break
+		}
+
+		select {
+		case <-done:
+			return res, ctx.Err()
+		default:
+		}
+
+		text, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return res, err
+		}
+		if text == "" && err == io.EOF {
+			break
+		}
+
+		cur := Match{
+			LineNumber: num,
+			Offset:     res.Bytes,
+			Line:       strings.TrimSuffix(text, "\n"),
+		}
+		res.Bytes += len(text)
+
+		var fnErr error
+		switch {
+		case (!limited || res.Selected < opts.MaxCount) && s.matcher.Match(cur.Line) != opts.Invert:
+			res.Selected++
+			fnErr = before.drain(emit)
+			if fnErr == nil {
+				fnErr = emit(cur)
+			}
+			afterLeft = opts.After
+
+		case afterLeft > 0:
+			cur.Context = true
+			fnErr = emit(cur)
+			afterLeft--
+
+		default:
+			cur.Context = true
+			before.push(cur)
+		}
+
+		if fnErr == ErrStop {
+			break
+		}
+		if fnErr != nil {
+			return res, fnErr
+		}
+
+		if err == io.EOF || (res.Binary && res.Selected > 0) {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// ring keeps the last cap(lines) unreported lines for -B context.
+type ring struct {
+	lines []Match
+	start int
+}
+
+// newRing returns a ring of size lines; with size zero or less it keeps none.
+func newRing(size int) *ring {
+	if size <= 0 {
+		return &ring{}
+	}
+
+	return &ring{
+		lines: make([]Match, 0, size),
+	}
+}
+
+func (r *ring) push(m Match) {
+	if cap(r.lines) == 0 {
+		return
+	}
+
+	if len(r.lines) < cap(r.lines) {
+		r.lines = append(r.lines, m)
+		return
+	}
+
+	r.lines[r.start] = m
+	r.start = (r.start + 1) % len(r.lines)
+}
+
+func (r *ring) drain(fn func(Match) error) error {
+	defer func() {
+		r.lines = r.lines[:0]
+		r.start = 0
+	}()
+
+	for i := range r.lines {
+		if err := fn(r.lines[(r.start+i)%len(r.lines)]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
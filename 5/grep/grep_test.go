@@ -0,0 +1,233 @@
+package grep
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func readInput(t *testing.T, kind string) []byte {
+	t.Helper()
+
+	text, err := os.ReadFile(filepath.Join("testdata", "input.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	switch kind {
+	case "binary":
+		return append([]byte("header\x00\n"), text...)
+	case "gzip":
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(text)
+		zw.Close()
+		return buf.Bytes()
+	case "zlib":
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(text)
+		zw.Close()
+		return buf.Bytes()
+	case "bzip2":
+		// The standard library cannot compress bzip2; input.txt.bz2 is
+		// input.txt packed with bzip2(1) and must be rebuilt with it.
+		data, err := os.ReadFile(filepath.Join("testdata", "input.txt.bz2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	case "zlib_header":
+		// "x^" is a valid zlib header without a preset dictionary.
+		return append([]byte("x^ = 5 ERROR\n"), text...)
+	case "backslash":
+		// Backslashes are literal inside POSIX brackets.
+		return append([]byte(`path C:\tmp\x`+"\n"), text...)
+	case "zlib_fdict":
+		// "80" is a valid zlib header that asks for a preset dictionary.
+		return append([]byte("8080 listening ERROR\n"), text...)
+	}
+
+	return text
+}
+
+// render prints every reported line as NUM:OFFSET:LINE for selected lines
+// and NUM-OFFSET-LINE for context, followed by its spans and the result.
+func render(matches []Match, res Result) string {
+	var b strings.Builder
+
+	for _, m := range matches {
+		sep := ':'
+		if m.Context {
+			sep = '-'
+		}
+		fmt.Fprintf(&b, "%d%c%d%c%s", m.LineNumber, sep, m.Offset, sep, m.Line)
+		if len(m.Spans) > 0 {
+			fmt.Fprintf(&b, " %v", m.Spans)
+		}
+		b.WriteByte('\n')
+	}
+
+	fmt.Fprintf(&b, "selected=%d bytes=%d binary=%v\n", res.Selected, res.Bytes, res.Binary)
+	return b.String()
+}
+
+func TestSearchGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+	}{
+		{name: "basic_literal", opts: Options{Patterns: []string{"ERROR"}}},
+		{name: "basic_plus_is_literal", opts: Options{Patterns: []string{"1+1"}, Spans: true}},
+		{name: "basic_gnu_extensions", opts: Options{Patterns: []string{`colou\?r`}, Spans: true}},
+		{name: "basic_group_interval", opts: Options{Patterns: []string{`\(ou\)\{1,2\}`}, Spans: true}},
+		{name: "basic_open_interval", opts: Options{Patterns: []string{`colou\{,1\}r`}, Spans: true}},
+		{name: "basic_bracket_backslash", input: "backslash", opts: Options{Patterns: []string{`[\n]`}, Spans: true}},
+		{name: "basic_bracket_lone_backslash", input: "backslash", opts: Options{Patterns: []string{`[\]`}, Spans: true}},
+		{name: "basic_anchors", opts: Options{Patterns: []string{`^2024-05-02.*ms$`}}},
+		{name: "extended_alternation", opts: Options{Patterns: []string{"ERROR|WARN"}, Syntax: Extended}},
+		{name: "extended_longest", opts: Options{Patterns: []string{"colou*|colouur"}, Syntax: Extended, Spans: true}},
+		{name: "perl_leftmost_first", opts: Options{Patterns: []string{"colou*?r"}, Syntax: Perl, Spans: true}},
+		{name: "perl_classes", opts: Options{Patterns: []string{`id=\d+`}, Syntax: Perl, Spans: true}},
+		{name: "fixed_metacharacters", opts: Options{Patterns: []string{"a.b"}, Syntax: Fixed, Spans: true}},
+		{name: "fixed_substring", opts: Options{Patterns: []string{"(attempt"}, Syntax: Fixed}},
+		{name: "ignore_case", opts: Options{Patterns: []string{"error"}, IgnoreCase: true, Spans: true}},
+		{name: "ignore_case_cyrillic", opts: Options{Patterns: []string{"привет"}, IgnoreCase: true, Spans: true}},
+		{name: "smart_case_lower", opts: Options{Patterns: []string{"error"}, SmartCase: true}},
+		{name: "smart_case_upper", opts: Options{Patterns: []string{"Error"}, SmartCase: true}},
+		{name: "smart_case_escape", opts: Options{Patterns: []string{`\Wmatches`}, Syntax: Perl, SmartCase: true}},
+		{name: "word_match", opts: Options{Patterns: []string{"foo"}, WordMatch: true, Spans: true}},
+		{name: "word_match_shorter", opts: Options{Patterns: []string{`foo-b\|foo`}, WordMatch: true, Spans: true}},
+		{name: "word_match_cyrillic", opts: Options{Patterns: []string{"привет"}, WordMatch: true, Spans: true}},
+		{name: "line_match", opts: Options{Patterns: []string{"2024-05-02 INFO shutting down"}, LineMatch: true}},
+		{name: "line_match_empty", opts: Options{Patterns: []string{""}, LineMatch: true}},
+		{name: "multiple_patterns", opts: Options{Patterns: []string{"WARN", "DEBUG", "panic"}}},
+		{name: "no_patterns", opts: Options{}},
+		{name: "no_patterns_invert", opts: Options{Invert: true}},
+		{name: "empty_pattern", opts: Options{Patterns: []string{""}}},
+		{name: "invert", opts: Options{Patterns: []string{"2024"}, Invert: true}},
+		{name: "invert_spans", opts: Options{Patterns: []string{"2024"}, Invert: true, After: 1, Spans: true}},
+		{name: "before_context", opts: Options{Patterns: []string{"ERROR"}, Before: 2}},
+		{name: "after_context", opts: Options{Patterns: []string{"ERROR"}, After: 1}},
+		{name: "context_overlap", opts: Options{Patterns: []string{"WARN", "мир"}, Before: 1, After: 1}},
+		{name: "negative_context", opts: Options{Patterns: []string{"ERROR"}, Before: -1, After: -1}},
+		{name: "context_at_edges", opts: Options{Patterns: []string{"started", "shutting"}, Before: 3, After: 3}},
+		{name: "max_count", opts: Options{Patterns: []string{"2024"}, MaxCount: 2}},
+		{name: "max_count_after_context", opts: Options{Patterns: []string{"ERROR"}, MaxCount: 1, After: 2}},
+		{name: "max_count_invert", opts: Options{Patterns: []string{"2024"}, Invert: true, MaxCount: 3}},
+		{name: "binary_report", input: "binary", opts: Options{Patterns: []string{"ERROR"}}},
+		{name: "binary_text", input: "binary", opts: Options{Patterns: []string{"ERROR"}, Binary: BinaryText}},
+		{name: "binary_skip", input: "binary", opts: Options{Patterns: []string{"ERROR"}, Binary: BinarySkip}},
+		{name: "decompress_gzip", input: "gzip", opts: Options{Patterns: []string{"ERROR"}, Decompress: true}},
+		{name: "decompress_plain", opts: Options{Patterns: []string{"ERROR"}, Decompress: true}},
+		{name: "decompress_zlib", input: "zlib", opts: Options{Patterns: []string{"ERROR"}, Decompress: true}},
+		{name: "decompress_bzip2", input: "bzip2", opts: Options{Patterns: []string{"ERROR"}, Decompress: true}},
+		{name: "decompress_zlib_header_text", input: "zlib_header", opts: Options{Patterns: []string{"ERROR"}, Decompress: true}},
+		{name: "decompress_zlib_fdict_text", input: "zlib_fdict", opts: Options{Patterns: []string{"ERROR"}, Decompress: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var matches []Match
+			res, err := Search(context.Background(), bytes.NewReader(readInput(t, tt.input)), tt.opts, func(m Match) error {
+				matches = append(matches, m)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := render(matches, res)
+			golden := filepath.Join("testdata", tt.name+".golden")
+
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("output mismatch\n--- got\n%s--- want\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSearchStop(t *testing.T) {
+	calls := 0
+	res, err := Search(context.Background(), bytes.NewReader(readInput(t, "")), Options{Patterns: []string{"2024"}}, func(m Match) error {
+		calls++
+		return ErrStop
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || res.Selected != 1 {
+		t.Errorf("calls = %d, selected = %d, want 1 and 1", calls, res.Selected)
+	}
+}
+
+func TestSearchCallbackError(t *testing.T) {
+	errBoom := errors.New("boom")
+	_, err := Search(context.Background(), bytes.NewReader(readInput(t, "")), Options{Patterns: []string{"2024"}}, func(m Match) error {
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("err = %v, want %v", err, errBoom)
+	}
+}
+
+func TestSearchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Search(ctx, bytes.NewReader(readInput(t, "")), Options{Patterns: []string{"2024"}}, func(m Match) error {
+		t.Error("callback called after cancellation")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNewSearcherErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "back_reference", opts: Options{Patterns: []string{`\(a\)\1`}}},
+		{name: "unmatched_bracket", opts: Options{Patterns: []string{"[abc"}}},
+		{name: "trailing_backslash", opts: Options{Patterns: []string{`abc\`}}},
+		{name: "invalid_extended", opts: Options{Patterns: []string{"a(b"}, Syntax: Extended}},
+		{name: "unmatched_interval", opts: Options{Patterns: []string{`a\{2`}}},
+		{name: "interval_not_a_number", opts: Options{Patterns: []string{`a\{x\}`}}},
+		{name: "interval_empty", opts: Options{Patterns: []string{`a\{\}`}}},
+		{name: "interval_decreasing", opts: Options{Patterns: []string{`a\{3,1\}`}}},
+		{name: "equivalence_class", opts: Options{Patterns: []string{"[[=a=]]"}}},
+		{name: "collating_symbol", opts: Options{Patterns: []string{"[[.a.]]"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSearcher(tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package grep
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Syntax selects how patterns are interpreted.
+type Syntax int
+
+const (
+	// Basic is POSIX basic regular expressions with GNU extensions (-G).
+	Basic Syntax = iota
+	// Extended is Go regexp syntax with leftmost-longest matching (-E).
+	Extended
+	// Fixed treats patterns as literal strings (-F).
+	Fixed
+	// Perl is Go regexp syntax with leftmost-first matching (-P).
+	Perl
+)
+
+// Matcher reports whether lines match a set of patterns. It is safe for
+// concurrent use.
+type Matcher struct {
+	re *regexp.Regexp
+	// word is set for -w: re wrapped in word boundaries, see wordLine.
+	word *regexp.Regexp
+}
+
+func newMatcher(opts Options) (*Matcher, error) {
+	// Like grep -f /dev/null, an empty pattern set matches nothing.
+	if len(opts.Patterns) == 0 {
+		return &Matcher{}, nil
+	}
+
+	alternatives := make([]string, 0, len(opts.Patterns))
+	for _, p := range opts.Patterns {
+		var err error
+
+		switch opts.Syntax {
+		case Fixed:
+			p = regexp.QuoteMeta(p)
+		case Basic:
+			p, err = translateBRE(p)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		alternatives = append(alternatives, "(?:"+p+")")
+	}
+
+	expr := strings.Join(alternatives, "|")
+	if opts.LineMatch {
+		expr = "^(?:" + expr + ")$"
+	}
+
+	ignoreCase := opts.IgnoreCase
+	if opts.SmartCase {
+		ignoreCase = !hasUpper(opts.Patterns, opts.Syntax)
+	}
+
+	// (?i) compares runes by Unicode simple folding, so Cyrillic and other
+	// cased scripts fold too, and lines are never rewritten for matching.
+	var flags string
+	if ignoreCase {
+		flags = "(?i)"
+	}
+
+	m := &Matcher{}
+	var err error
+	if m.re, err = compile(flags+expr, opts.Syntax); err != nil {
+		return nil, err
+	}
+
+	// A word match must sit between non-word characters. Compiling that into
+	// the expression lets the engine fall back to shorter or later matches,
+	// as GNU grep does, when the longest one is not a whole word.
+	if opts.WordMatch && !opts.LineMatch {
+		if m.word, err = compile(flags+"(?m)"+nonWord+"("+expr+")"+nonWord, opts.Syntax); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// nonWord matches one rune that is not a letter, a digit or '_'.
+const nonWord = `[^\pL\p{Nd}_]`
+
+func compile(expr string, syntax Syntax) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// GNU grep reports the leftmost-longest match for BRE, ERE and fixed strings.
+	if syntax != Perl {
+		re.Longest()
+	}
+
+	return re, nil
+}
+
+// hasUpper reports whether any pattern contains an upper-case letter. Escape
+// sequences such as \W or \p{Lu} are not literal letters and are skipped.
+func hasUpper(patterns []string, mode Syntax) bool {
+	for _, p := range patterns {
+		for i := 0; i < len(p); {
+			r, size := utf8.DecodeRuneInString(p[i:])
+
+			if r == '\\' && mode != Fixed && i+size < len(p) {
+				i += size
+				next, nextSize := utf8.DecodeRuneInString(p[i:])
+				i += nextSize
+				if (next == 'p' || next == 'P') && strings.HasPrefix(p[i:], "{") {
+					if end := strings.IndexByte(p[i:], '}'); end >= 0 {
+						i += end + 1
+					}
+				} else if next == 'p' || next == 'P' {
+					i++
+				}
+				continue
+			}
+
+			if unicode.IsUpper(r) {
+				return true
+			}
+			i += size
+		}
+	}
+
+	return false
+}
+
+// Match reports whether line contains a match.
+func (m *Matcher) Match(line string) bool {
+	switch {
+	case m.re == nil:
+		return false
+	case m.word != nil:
+		return m.word.MatchString(wordLine(line))
+	}
+
+	return m.re.MatchString(line)
+}
+
+// Spans returns the byte spans of every match in line.
+func (m *Matcher) Spans(line string) [][]int {
+	switch {
+	case m.re == nil:
+		return nil
+	case m.word == nil:
+		return m.re.FindAllStringIndex(line, -1)
+	}
+
+	text := wordLine(line)
+	var spans [][]int
+
+	for pos := 0; pos < len(text); {
+		loc := m.word.FindStringSubmatchIndex(text[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[2], pos+loc[3]
+		spans = append(spans, []int{start - 1, end - 1})
+
+		// The next match may use the last rune of this one as its leading
+		// non-word rune; an empty match moves on by one rune.
+		if start == end {
+			pos = end
+		} else {
+			_, size := utf8.DecodeLastRuneInString(text[:end])
+			pos = end - size
+		}
+	}
+
+	return spans
+}
+
+// wordLine wraps line in newlines that stand for its edges: they are
+// non-word runes for the -w expression, and under (?m) ^ and $ still match
+// at the line's ends. Lines never contain a newline themselves.
+func wordLine(line string) string {
+	return "\n" + line + "\n"
+}
+
+// translateBRE rewrites a POSIX basic regular expression with the GNU
+// extensions (\+, \?, \|) into Go regexp syntax.
+func translateBRE(p string) (string, error) {
+	var b strings.Builder
+	atStart := true
+
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+
+		switch c {
+		case '\\':
+			if i+1 == len(p) {
+				return "", errors.New("trailing backslash")
+			}
+			i++
+			next := p[i]
+
+			switch next {
+			case '{':
+				interval, n, err := translateInterval(p[i+1:])
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(interval)
+				i += n
+			case '(', ')', '}', '|', '+', '?':
+				b.WriteByte(next)
+				atStart = next == '(' || next == '|'
+				continue
+			case '<', '>':
+				b.WriteString(`\b`)
+			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				return "", fmt.Errorf("back-references are not supported: \\%c", next)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(next)
+			}
+
+		case '(', ')', '{', '}', '|', '+', '?':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+
+		case '*':
+			if atStart {
+				b.WriteString(`\*`)
+			} else {
+				b.WriteByte(c)
+			}
+
+		case '^':
+			if atStart {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteString(`\^`)
+
+		case '$':
+			if i+1 == len(p) || strings.HasPrefix(p[i+1:], `\)`) || strings.HasPrefix(p[i+1:], `\|`) {
+				b.WriteByte(c)
+			} else {
+				b.WriteString(`\$`)
+			}
+
+		case '[':
+			end, err := bracketEnd(p, i)
+			if err != nil {
+				return "", err
+			}
+			// A backslash is literal inside POSIX brackets but an escape in
+			// Go; character class names never contain one.
+			b.WriteString(strings.ReplaceAll(p[i:end+1], `\`, `\\`))
+			i = end
+
+		default:
+			b.WriteByte(c)
+		}
+
+		atStart = false
+	}
+
+	return b.String(), nil
+}
+
+// translateInterval converts the body of a \{...\} interval at the start of p
+// to Go syntax and returns how many bytes of p it used, up to the closing \}.
+// Go would silently treat a malformed interval as literal text, so it is
+// validated here the way GNU grep does.
+func translateInterval(p string) (string, int, error) {
+	end := strings.Index(p, `\}`)
+	if end < 0 {
+		return "", 0, errors.New(`unmatched \{`)
+	}
+
+	body := p[:end]
+	invalid := fmt.Errorf(`invalid content of \{\}: %q`, body)
+
+	lo, hi, isRange := strings.Cut(body, ",")
+	if lo == "" && isRange {
+		lo = "0"
+	}
+	if !isCount(lo) || hi != "" && !isCount(hi) {
+		return "", 0, invalid
+	}
+	if hi != "" {
+		from, _ := strconv.Atoi(lo)
+		to, _ := strconv.Atoi(hi)
+		if to < from {
+			return "", 0, invalid
+		}
+	}
+
+	interval := "{" + lo
+	if isRange {
+		interval += "," + hi
+	}
+	return interval + "}", end + 2, nil
+}
+
+func isCount(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// bracketEnd returns the index of the ']' that closes the bracket expression
+// starting at p[start].
+func bracketEnd(p string, start int) (int, error) {
+	i := start + 1
+	if i < len(p) && p[i] == '^' {
+		i++
+	}
+	if i < len(p) && p[i] == ']' {
+		i++
+	}
+
+	for ; i < len(p); i++ {
+		switch {
+		case p[i] == '[' && i+1 < len(p) && (p[i+1] == '=' || p[i+1] == '.'):
+			return 0, errors.New("equivalence classes [= =] and collating symbols [. .] are not supported")
+		case p[i] == '[' && i+1 < len(p) && p[i+1] == ':':
+			end := strings.Index(p[i+2:], ":]")
+			if end < 0 {
+				return 0, errors.New("unterminated character class")
+			}
+			i += end + 3
+		case p[i] == ']':
+			return i, nil
+		}
+	}
+
+	return 0, errors.New("unmatched [")
+}
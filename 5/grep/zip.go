@@ -0,0 +1,69 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	errZstd = errors.New("zstd compressed input is not supported")
+)
+
+// zlibProbeSize is how much input is test-decoded before it is treated as
+// zlib, whose two-byte header also fits plenty of plain text.
+const zlibProbeSize = 4 << 10
+
+// decompress detects gzip, bzip2 and zlib streams by their magic bytes and
+// returns a reader over the decoded data. Any other input is returned as is.
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReaderSize(r, zlibProbeSize)
+	head, err := br.Peek(zlibProbeSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(head, bzip2Magic):
+		return io.NopCloser(bzip2.NewReader(br)), nil
+	case isZlibHeader(head):
+		return zlib.NewReader(br)
+	case bytes.HasPrefix(head, zstdMagic):
+		return nil, errZstd
+	}
+
+	return io.NopCloser(br), nil
+}
+
+// isZlibHeader checks the RFC 1950 header: deflate method, a window of at most
+// 32K, no preset dictionary and a CMF/FLG pair divisible by 31. As text such
+// as "x^" passes that too, the start of the stream must also decode.
+func isZlibHeader(head []byte) bool {
+	if len(head) < 2 {
+		return false
+	}
+
+	cmf, flg := head[0], head[1]
+	if cmf&0x0f != 8 || cmf>>4 > 7 || flg&0x20 != 0 || (uint16(cmf)<<8|uint16(flg))%31 != 0 {
+		return false
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(head))
+	if err != nil {
+		return false
+	}
+
+	// Running out of probe bytes is fine; corrupt data is not.
+	_, err = io.Copy(io.Discard, zr)
+	return err == nil || err == io.ErrUnexpectedEOF
+}
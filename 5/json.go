@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Cagge/lvl2/5/grep"
+)
+
+// data carries text as UTF-8 when it is valid and base64-encoded bytes
+// otherwise, so records never silently replace input bytes.
+type data struct {
+	Text  *string `json:"text,omitempty"`
+	Bytes []byte  `json:"bytes,omitempty"`
+}
+
+func newData(s string) data {
+	if utf8.ValidString(s) {
+		return data{Text: &s}
+	}
+
+	return data{Bytes: []byte(s)}
+}
+
+type submatch struct {
+	Match data `json:"match"`
+	Start int  `json:"start"`
+	End   int  `json:"end"`
+}
+
+type lineRecord struct {
+	Path           data       `json:"path"`
+	Lines          data       `json:"lines"`
+	LineNumber     int        `json:"line_number"`
+	AbsoluteOffset int        `json:"absolute_offset"`
+	Submatches     []submatch `json:"submatches"`
+}
+
+type pathRecord struct {
+	Path data `json:"path"`
+}
+
+type endRecord struct {
+	Path   data      `json:"path"`
+	Binary bool      `json:"binary"`
+	Stats  fileTotal `json:"stats"`
+}
+
+type fileTotal struct {
+	MatchedLines  int `json:"matched_lines"`
+	Matches       int `json:"matches"`
+	BytesSearched int `json:"bytes_searched"`
+}
+
+type summaryRecord struct {
+	ElapsedNanos int64        `json:"elapsed_nanos"`
+	Stats        summaryStats `json:"stats"`
+}
+
+type summaryStats struct {
+	Searches      int `json:"searches"`
+	SearchesMatch int `json:"searches_with_match"`
+	MatchedLines  int `json:"matched_lines"`
+	BytesSearched int `json:"bytes_searched"`
+}
+
+type record struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// jsonPrinter writes one JSON Lines record per event in the shape of
+// ripgrep's --json output.
+type jsonPrinter struct {
+	cfg     *grepConfig
+	w       *bufio.Writer
+	name    string
+	matches int
+}
+
+func (p *jsonPrinter) write(kind string, v any) {
+	json.NewEncoder(p.w).Encode(record{
+		Type: kind,
+		Data: v,
+	})
+}
+
+func (p *jsonPrinter) begin() {
+	p.write("begin", pathRecord{
+		Path: newData(p.name),
+	})
+}
+
+func (p *jsonPrinter) line(m grep.Match) {
+	submatches := make([]submatch, 0, len(m.Spans))
+	for _, span := range m.Spans {
+		submatches = append(submatches, submatch{
+			Match: newData(m.Line[span[0]:span[1]]),
+			Start: span[0],
+			End:   span[1],
+		})
+	}
+
+	kind := "match"
+	if m.Context {
+		kind = "context"
+	} else {
+		p.matches += len(submatches)
+	}
+
+	p.write(kind, lineRecord{
+		Path:           newData(p.name),
+		Lines:          newData(m.Line + "\n"),
+		LineNumber:     m.LineNumber,
+		AbsoluteOffset: m.Offset,
+		Submatches:     submatches,
+	})
+}
+
+func (p *jsonPrinter) end(stats fileStats) {
+	p.write("end", endRecord{
+		Path:   newData(p.name),
+		Binary: stats.binary,
+		Stats: fileTotal{
+			MatchedLines:  stats.selected,
+			Matches:       p.matches,
+			BytesSearched: stats.bytes,
+		},
+	})
+}
+
+func writeSummary(w io.Writer, sum summary, elapsed time.Duration) {
+	json.NewEncoder(w).Encode(record{
+		Type: "summary",
+		Data: summaryRecord{
+			ElapsedNanos: elapsed.Nanoseconds(),
+			Stats: summaryStats{
+				Searches:      sum.files,
+				SearchesMatch: sum.filesMatched,
+				MatchedLines:  sum.selected,
+				BytesSearched: sum.bytes,
+			},
+		},
+	})
+}
@@ -1,155 +1,225 @@
-package main
-
-import (
-	"bufio"
-	"flag"
-	"fmt"
-	"log"
-	"os"
-	"strings"
-)
-
-type grepConfig struct {
-	lines      []string
-	after      int
-	before     int
-	ctx        int
-	count      bool
-	ignoreCase bool
-	invert     bool
-	fixed      bool
-	lineNum    bool
-	result     map[int]string
-	counter    int
-}
-
-func grep(cfg *grepConfig, target string) {
-	for i, line := range cfg.lines {
-		if cfg.ignoreCase {
-			line = strings.ToLower(line)
-			target = strings.ToLower(target)
-		}
-
-		if cfg.fixed {
-			if line == target {
-				process(cfg, i)
-			}
-			continue
-		}
-
-		if strings.Contains(line, target) {
-			process(cfg, i)
-		}
-	}
-}
-
-func process(cfg *grepConfig, idx int) {
-	start := idx
-	end := idx
-
-	if cfg.ctx > 0 {
-		start -= cfg.ctx
-		end += cfg.ctx
-	}
-
-	if cfg.before > 0 {
-		start -= cfg.before
-	}
-
-	if cfg.after > 0 {
-		end += cfg.after
-	}
-
-	if start < 0 {
-		start = 0
-	}
-
-	if end >= len(cfg.lines) {
-		end = len(cfg.lines) - 1
-	}
-
-	for i := start; i <= end; i++ {
-		if cfg.lineNum {
-			if i == idx {
-				cfg.result[i] = fmt.Sprintf("%d:%s", i+1, cfg.lines[i])
-				cfg.counter++
-			} else {
-				cfg.result[i] = fmt.Sprintf("%d-%s", i+1, cfg.lines[i])
-			}
-			continue
-		}
-
-		cfg.result[i] = cfg.lines[i]
-		cfg.counter++
-	}
-}
-
-func (cfg *grepConfig) printResult() {
-	if cfg.count {
-		fmt.Println(cfg.counter)
-		return
-	}
-
-	if cfg.invert {
-		for i, s := range cfg.lines {
-			if _, ok := cfg.result[i]; !ok {
-				fmt.Println(s)
-			}
-		}
-		return
-	}
-
-	for _, line := range cfg.result {
-		fmt.Println(line)
-	}
-}
-
-func main() {
-	after := flag.Int("A", 0, "Print +N lines after match")
-	before := flag.Int("B", 0, "Print +N lines before match")
-	ctx := flag.Int("C", 0, "Print ±N lines before and after match")
-	count := flag.Bool("c", false, "Print number of matches")
-	ignoreCase := flag.Bool("i", false, "Ignore case")
-	invert := flag.Bool("v", false, "Invert matches")
-	fixed := flag.Bool("F", false, "Exact match to line, not pattern")
-	lineNum := flag.Bool("n", false, "Print number of line")
-
-	flag.Parse()
-
-	input := os.Args[len(os.Args)-1]
-	inputFile, err := os.Open(input)
-	if err != nil {
-		log.Fatal("Failed to open input file:", err.Error())
-	}
-
-	defer inputFile.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(inputFile)
-
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Fatal("Error reading input file:", err)
-	}
-
-	target := os.Args[len(os.Args)-2]
-
-	cfg := &grepConfig{
-		lines:      lines,
-		after:      *after,
-		before:     *before,
-		ctx:        *ctx,
-		count:      *count,
-		ignoreCase: *ignoreCase,
-		invert:     *invert,
-		fixed:      *fixed,
-		lineNum:    *lineNum,
-		result:     make(map[int]string),
-	}
-
-	grep(cfg, target)
-	cfg.printResult()
-}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/Cagge/lvl2/5/grep"
+)
+
+func main() {
+	after := flag.Int("A", 0, "Print +N lines after match")
+	before := flag.Int("B", 0, "Print +N lines before match")
+	ctx := flag.Int("C", 0, "Print ±N lines before and after match")
+	count := flag.Bool("c", false, "Print number of selected lines")
+	maxCount := flag.Int("m", -1, "Stop reading a file after `NUM` selected lines")
+	quiet := flag.Bool("q", false, "Print nothing, exit with status 0 on the first selected line")
+	ignoreCase := flag.Bool("i", false, "Ignore case distinctions using Unicode case folding")
+	smartCase := flag.Bool("smart-case", false, "Ignore case only if all patterns are lower case")
+	invert := flag.Bool("v", false, "Invert matches")
+	fixed := flag.Bool("F", false, "Interpret patterns as fixed strings")
+	basic := flag.Bool("G", false, "Interpret patterns as basic regular expressions (default)")
+	extended := flag.Bool("E", false, "Interpret patterns as extended regular expressions")
+	perl := flag.Bool("P", false, "Interpret patterns as Perl-style regular expressions")
+	wordMatch := flag.Bool("w", false, "Match only whole words")
+	lineMatch := flag.Bool("x", false, "Match only whole lines")
+	lineNum := flag.Bool("n", false, "Print number of line")
+	var patterns stringsFlag
+	flag.Var(&patterns, "e", "Use `PATTERN` for matching (may be repeated)")
+	var patternFiles stringsFlag
+	flag.Var(&patternFiles, "f", "Take patterns from `FILE`, one per line (may be repeated)")
+	recursive := flag.Bool("r", false, "Search directories recursively")
+	dereference := flag.Bool("R", false, "Search directories recursively, following symlinks")
+	noIgnore := flag.Bool("no-ignore", false, "Do not skip files listed in .gitignore during recursive search")
+	var include, exclude, excludeDir stringsFlag
+	flag.Var(&include, "include", "Search only files whose base name matches `GLOB`")
+	flag.Var(&exclude, "exclude", "Skip files whose base name matches `GLOB`")
+	flag.Var(&excludeDir, "exclude-dir", "Skip directories whose base name matches `GLOB`")
+	filesWithMatches := flag.Bool("l", false, "Print only names of files with matches")
+	filesWithoutMatch := flag.Bool("L", false, "Print only names of files without matches")
+	withFilename := flag.Bool("H", false, "Print the file name for each match")
+	noFilename := flag.Bool("h", false, "Never print file names")
+	text := flag.Bool("a", false, "Process binary files as text")
+	skipBinary := flag.Bool("I", false, "Skip binary files")
+	color := flag.String("color", "auto", "Highlight matches: `WHEN` is never, always or auto")
+	onlyMatching := flag.Bool("o", false, "Print only the matched parts of a line")
+	byteOffset := flag.Bool("b", false, "Print the byte offset of each output line")
+	column := flag.Bool("column", false, "Print the column of the first match")
+	searchZip := flag.Bool("z", false, "Search in gzip, bzip2 and zlib compressed files")
+	flag.BoolVar(searchZip, "search-zip", false, "Same as -z")
+	jsonOutput := flag.Bool("json", false, "Print results as JSON Lines records")
+	jobs := flag.Int("j", runtime.NumCPU(), "Search up to `N` files at the same time")
+
+	flag.Parse()
+
+	for _, name := range patternFiles {
+		filePatterns, err := readPatternFile(name)
+		if err != nil {
+			fatal("Failed to read pattern file:", err)
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+
+	args := flag.Args()
+	if len(patterns) == 0 && len(patternFiles) == 0 {
+		if len(args) == 0 {
+			fatal("Usage: grep [OPTION]... PATTERN [FILE]...")
+		}
+		patterns = append(patterns, args[0])
+		args = args[1:]
+	}
+
+	for _, n := range []int{*after, *before, *ctx} {
+		if n < 0 {
+			fatal(fmt.Sprintf("%d: invalid context length argument", n))
+		}
+	}
+
+	opts := grep.Options{
+		Patterns:   patterns,
+		IgnoreCase: *ignoreCase,
+		SmartCase:  *smartCase,
+		WordMatch:  *wordMatch,
+		LineMatch:  *lineMatch,
+		Invert:     *invert,
+		Before:     *ctx,
+		After:      *ctx,
+		MaxCount:   *maxCount,
+		Decompress: *searchZip,
+	}
+
+	// As in GNU grep, an explicit -A or -B overrides -C.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "A":
+			opts.After = *after
+		case "B":
+			opts.Before = *before
+		}
+	})
+
+	switch {
+	case *fixed:
+		opts.Syntax = grep.Fixed
+	case *perl:
+		opts.Syntax = grep.Perl
+	case *extended:
+		opts.Syntax = grep.Extended
+	case *basic:
+		opts.Syntax = grep.Basic
+	}
+
+	switch {
+	case *text:
+		opts.Binary = grep.BinaryText
+	case *skipBinary:
+		opts.Binary = grep.BinarySkip
+	case *count:
+		// GNU grep counts the lines of binary files like text.
+		opts.Binary = grep.BinaryText
+	}
+
+	// Context has nothing to show when only counts, names or the matched
+	// parts of lines are printed.
+	if *count || *quiet || *filesWithMatches || *filesWithoutMatch || *onlyMatching {
+		opts.Before, opts.After = 0, 0
+	}
+
+	// -m 0 selects nothing, so no input has to be read.
+	if *maxCount == 0 {
+		os.Exit(1)
+	}
+
+	if *jsonOutput && (*count || *quiet || *filesWithMatches || *filesWithoutMatch) {
+		fatal("--json cannot be combined with -c, -q, -l or -L")
+	}
+
+	colors, err := newColors(*color, os.Getenv("GREP_COLORS"))
+	if err != nil {
+		fatal(err)
+	}
+	if *jsonOutput {
+		colors.enabled = false
+	}
+
+	opts.Spans = colors.enabled || *onlyMatching || *column || *jsonOutput
+	searcher, err := grep.NewSearcher(opts)
+	if err != nil {
+		fatal("Invalid pattern:", err)
+	}
+
+	walk := walkConfig{
+		recursive:   *recursive || *dereference,
+		followLinks: *dereference,
+		useIgnore:   !*noIgnore,
+		include:     include,
+		exclude:     exclude,
+		excludeDir:  excludeDir,
+	}
+
+	if len(args) == 0 {
+		if walk.recursive {
+			args = []string{"."}
+		} else {
+			args = []string{"-"}
+		}
+	}
+
+	failed := false
+	onErr := func(err error) {
+		failed = true
+		log.Println(err)
+	}
+
+	files := collectFiles(walk, args, onErr)
+
+	cfg := &grepConfig{
+		searcher: searcher,
+		context:  opts.Before > 0 || opts.After > 0,
+		count:    *count,
+		lineNum:  *lineNum,
+		withName: (len(files) > 1 || walk.recursive || *withFilename) && !*noFilename,
+		quiet:    *quiet,
+		json:     *jsonOutput,
+
+		onlyMatching: *onlyMatching,
+		byteOffset:   *byteOffset,
+		column:       *column,
+		colors:       colors,
+	}
+
+	switch {
+	case *filesWithMatches:
+		cfg.list = listMatching
+	case *filesWithoutMatch:
+		cfg.list = listNonMatching
+	}
+
+	start := time.Now()
+	sum := searchFiles(cfg, files, *jobs, os.Stdout, onErr)
+	if cfg.json {
+		writeSummary(os.Stdout, sum, time.Since(start))
+	}
+
+	matched := sum.selected > 0
+
+	// Exit status follows GNU grep: 0 if a line was selected, 1 if none was,
+	// 2 on error unless -q already found a match.
+	switch {
+	case matched && (cfg.quiet || !failed):
+		os.Exit(0)
+	case failed:
+		os.Exit(2)
+	default:
+		os.Exit(1)
+	}
+}
+
+func fatal(v ...any) {
+	log.Println(v...)
+	os.Exit(2)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+type fileResult struct {
+	out   []byte
+	stats fileStats
+	err   error
+}
+
+type summary struct {
+	files        int
+	filesMatched int
+	selected     int
+	bytes        int
+}
+
+func (s *summary) add(stats fileStats) {
+	s.files++
+	s.selected += stats.selected
+	s.bytes += stats.bytes
+	if stats.selected > 0 {
+		s.filesMatched++
+	}
+}
+
+// searchFiles searches files with up to jobs workers. Each file is rendered
+// into its own buffer and the buffers are written to w in the order of files,
+// so the output does not depend on scheduling. At most a few buffers per
+// worker are held at once. With -q it returns as soon as a line is selected.
+// Group separators between files are decided here, in output order.
+func searchFiles(cfg *grepConfig, files []string, jobs int, w io.Writer, onErr func(error)) summary {
+	var sum summary
+	grouped := false
+
+	if jobs <= 1 || len(files) <= 1 {
+		out := bufio.NewWriter(w)
+		defer out.Flush()
+
+		for _, name := range files {
+			stats, err := searchFile(cfg, name, out, grouped)
+			if err != nil {
+				out.Flush()
+				onErr(err)
+			}
+			grouped = grouped || stats.printed
+			sum.add(stats)
+			if sum.selected > 0 && cfg.quiet {
+				break
+			}
+		}
+		return sum
+	}
+
+	results := make([]chan fileResult, len(files))
+	for i := range results {
+		results[i] = make(chan fileResult, 1)
+	}
+
+	window := make(chan struct{}, 4*jobs)
+	indexes := make(chan int)
+
+	go func() {
+		defer close(indexes)
+		for i := range files {
+			window <- struct{}{}
+			indexes <- i
+		}
+	}()
+
+	for j := 0; j < jobs; j++ {
+		go func() {
+			for i := range indexes {
+				var buf bytes.Buffer
+				out := bufio.NewWriter(&buf)
+				stats, err := searchFile(cfg, files[i], out, false)
+				out.Flush()
+
+				results[i] <- fileResult{
+					out:   buf.Bytes(),
+					stats: stats,
+					err:   err,
+				}
+			}
+		}()
+	}
+
+	out := bufio.NewWriter(w)
+	for _, result := range results {
+		res := <-result
+		<-window
+
+		if grouped && res.stats.printed {
+			cfg.groupSeparator(out)
+		}
+		out.Write(res.out)
+		out.Flush()
+		if res.err != nil {
+			onErr(res.err)
+		}
+		grouped = grouped || res.stats.printed
+		sum.add(res.stats)
+		if sum.selected > 0 && cfg.quiet {
+			break
+		}
+	}
+
+	return sum
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Cagge/lvl2/5/grep"
+)
+
+func generateTree(b *testing.B, dirs, filesPerDir, linesPerFile int) string {
+	b.Helper()
+
+	root := b.TempDir()
+	var content strings.Builder
+	for i := 0; i < linesPerFile; i++ {
+		if i%97 == 0 {
+			fmt.Fprintf(&content, "%d: ERROR request failed with status 503\n", i)
+			continue
+		}
+		fmt.Fprintf(&content, "%d: INFO request served in %dms\n", i, i%250)
+	}
+
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%03d", d))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		for f := 0; f < filesPerDir; f++ {
+			name := filepath.Join(dir, fmt.Sprintf("file%03d.log", f))
+			if err := os.WriteFile(name, []byte(content.String()), 0o644); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	return root
+}
+
+func BenchmarkSearchFiles(b *testing.B) {
+	root := generateTree(b, 20, 50, 2000)
+
+	searcher, err := grep.NewSearcher(grep.Options{
+		Patterns: []string{`ERROR.*50[0-9]`},
+		Syntax:   grep.Extended,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	files := collectFiles(walkConfig{recursive: true}, []string{root}, func(err error) {
+		b.Fatal(err)
+	})
+
+	cfg := &grepConfig{
+		searcher: searcher,
+		lineNum:  true,
+		withName: true,
+	}
+
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				searchFiles(cfg, files, jobs, io.Discard, func(err error) {
+					b.Fatal(err)
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"strconv"
+
+	"github.com/Cagge/lvl2/5/grep"
+)
+
+type printer struct {
+	cfg  *grepConfig
+	w    *bufio.Writer
+	name string
+	// separate is set when an earlier file already printed a group, so the
+	// first line of this file starts a new one.
+	separate    bool
+	lastPrinted int
+}
+
+func (p *printer) begin() {}
+
+func (p *printer) line(m grep.Match) {
+	c := &p.cfg.colors
+
+	if p.lastPrinted > 0 && m.LineNumber != p.lastPrinted+1 || p.lastPrinted == 0 && p.separate {
+		p.cfg.groupSeparator(p.w)
+	}
+	p.lastPrinted = m.LineNumber
+
+	sep := byte(':')
+	lineColor, matchColor := c.selectedLine, c.selectedMatch
+	if m.Context {
+		sep = '-'
+		lineColor, matchColor = c.contextLine, c.contextMatch
+	}
+
+	if p.cfg.onlyMatching {
+		for _, span := range m.Spans {
+			if span[0] == span[1] {
+				continue
+			}
+			p.head(m, sep, span[0]+1, m.Offset+span[0])
+			c.paint(p.w, matchColor, m.Line[span[0]:span[1]])
+			p.w.WriteByte('\n')
+		}
+		return
+	}
+
+	column := 1
+	if len(m.Spans) > 0 {
+		column = m.Spans[0][0] + 1
+	}
+	p.head(m, sep, column, m.Offset)
+
+	pos := 0
+	for _, span := range m.Spans {
+		c.paint(p.w, lineColor, m.Line[pos:span[0]])
+		c.paint(p.w, matchColor, m.Line[span[0]:span[1]])
+		pos = span[1]
+	}
+	c.paint(p.w, lineColor, m.Line[pos:])
+	p.w.WriteByte('\n')
+}
+
+// head writes the file name, line number, column and byte offset prefix.
+func (p *printer) head(m grep.Match, sep byte, column, offset int) {
+	c := &p.cfg.colors
+
+	if p.cfg.withName {
+		c.paint(p.w, c.fileName, p.name)
+		c.paint(p.w, c.separator, string(sep))
+	}
+	if p.cfg.lineNum {
+		c.paint(p.w, c.lineNum, strconv.Itoa(m.LineNumber))
+		c.paint(p.w, c.separator, string(sep))
+	}
+	if p.cfg.column {
+		c.paint(p.w, c.lineNum, strconv.Itoa(column))
+		c.paint(p.w, c.separator, string(sep))
+	}
+	if p.cfg.byteOffset {
+		c.paint(p.w, c.byteOffset, strconv.Itoa(offset))
+		c.paint(p.w, c.separator, string(sep))
+	}
+}
+
+func (p *printer) fileName(sep string) {
+	c := &p.cfg.colors
+	c.paint(p.w, c.fileName, p.name)
+	c.paint(p.w, c.separator, sep)
+}
+
+func (p *printer) end(stats fileStats) {
+	switch {
+	case p.cfg.quiet:
+	case p.cfg.list == listMatching:
+		if stats.selected > 0 {
+			p.fileName("\n")
+		}
+	case p.cfg.list == listNonMatching:
+		if stats.selected == 0 {
+			p.fileName("\n")
+		}
+	case p.cfg.count:
+		if p.cfg.withName {
+			p.fileName(":")
+		}
+		p.w.WriteString(strconv.Itoa(stats.selected))
+		p.w.WriteByte('\n')
+	case stats.binary && stats.selected > 0:
+		p.w.WriteString("Binary file " + p.name + " matches\n")
+	}
+}
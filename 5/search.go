@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Cagge/lvl2/5/grep"
+)
+
+type listMode int
+
+const (
+	listNone listMode = iota
+	listMatching
+	listNonMatching
+)
+
+const stdinName = "(standard input)"
+
+var errIsDirectory = errors.New("Is a directory")
+
+// grepConfig holds the output settings of the command; matching itself is
+// configured on searcher.
+type grepConfig struct {
+	searcher *grep.Searcher
+	context  bool
+	count    bool
+	lineNum  bool
+	withName bool
+	list     listMode
+	quiet    bool
+	json     bool
+
+	onlyMatching bool
+	byteOffset   bool
+	column       bool
+	colors       colors
+}
+
+type fileStats struct {
+	name     string
+	selected int
+	bytes    int
+	binary   bool
+	// printed is set when lines were written, so the file took part in the
+	// -- separated groups of context output.
+	printed bool
+}
+
+// sink receives the lines of one file selected for output.
+type sink interface {
+	begin()
+	line(m grep.Match)
+	end(stats fileStats)
+}
+
+// groupSeparator writes the -- line that GNU grep puts between groups of
+// context output, within a file and across files.
+func (cfg *grepConfig) groupSeparator(w *bufio.Writer) {
+	if !cfg.context || cfg.json {
+		return
+	}
+
+	cfg.colors.paint(w, cfg.colors.separator, "--")
+	w.WriteByte('\n')
+}
+
+// searchFile writes the output for one file to w. With separate set the
+// first printed line is preceded by a group separator.
+func searchFile(cfg *grepConfig, name string, w *bufio.Writer, separate bool) (fileStats, error) {
+	var r io.Reader = os.Stdin
+	if name == "-" {
+		name = stdinName
+	} else {
+		file, err := os.Open(name)
+		if err != nil {
+			return fileStats{name: name}, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var out sink = &printer{
+		cfg:      cfg,
+		w:        w,
+		name:     name,
+		separate: separate,
+	}
+	if cfg.json {
+		out = &jsonPrinter{
+			cfg:  cfg,
+			w:    w,
+			name: name,
+		}
+	}
+
+	// -q, -l and -L only need to know whether a line is selected; -c keeps
+	// going to count every one.
+	printLines := !cfg.quiet && !cfg.count && cfg.list == listNone
+
+	printed := false
+	out.begin()
+	res, err := cfg.searcher.Search(context.Background(), r, func(m grep.Match) error {
+		if !printLines {
+			if cfg.count {
+				return nil
+			}
+			return grep.ErrStop
+		}
+		out.line(m)
+		printed = true
+		return nil
+	})
+
+	stats := fileStats{
+		name:     name,
+		selected: res.Selected,
+		bytes:    res.Bytes,
+		binary:   res.Binary,
+		printed:  printed,
+	}
+	if err != nil {
+		return stats, fmt.Errorf("%s: %w", name, err)
+	}
+
+	out.end(stats)
+	return stats, nil
+}
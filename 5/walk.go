@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type walkConfig struct {
+	recursive   bool
+	followLinks bool
+	useIgnore   bool
+	include     []string
+	exclude     []string
+	excludeDir  []string
+}
+
+// collectFiles expands the file operands into the list of files to search,
+// in the order GNU grep visits them. Errors for individual operands are
+// passed to onErr and do not stop the walk.
+func collectFiles(cfg walkConfig, operands []string, onErr func(error)) []string {
+	var files []string
+
+	for _, operand := range operands {
+		if operand == "-" {
+			files = append(files, operand)
+			continue
+		}
+
+		info, err := os.Stat(operand)
+		if err != nil {
+			onErr(err)
+			continue
+		}
+
+		if !info.IsDir() {
+			if cfg.selected(filepath.Base(operand)) {
+				files = append(files, operand)
+			}
+			continue
+		}
+
+		if !cfg.recursive {
+			onErr(&fs.PathError{Op: "read", Path: operand, Err: errIsDirectory})
+			continue
+		}
+
+		w := &walker{
+			cfg:     cfg,
+			onErr:   onErr,
+			visited: make(map[string]struct{}),
+		}
+		w.walk(operand, nil)
+		files = append(files, w.files...)
+	}
+
+	return files
+}
+
+func (cfg walkConfig) selected(name string) bool {
+	for _, glob := range cfg.exclude {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return false
+		}
+	}
+
+	if len(cfg.include) == 0 {
+		return true
+	}
+
+	for _, glob := range cfg.include {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cfg walkConfig) dirSelected(name string) bool {
+	for _, glob := range cfg.excludeDir {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+type walker struct {
+	cfg     walkConfig
+	onErr   func(error)
+	visited map[string]struct{}
+	files   []string
+}
+
+func (w *walker) walk(dir string, rules []ignoreRule) {
+	if w.cfg.followLinks {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			w.onErr(err)
+			return
+		}
+		if _, ok := w.visited[real]; ok {
+			return
+		}
+		w.visited[real] = struct{}{}
+	}
+
+	if w.cfg.useIgnore {
+		local, err := loadIgnoreFile(dir)
+		if err != nil {
+			w.onErr(err)
+		}
+		rules = append(rules[:len(rules):len(rules)], local...)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		w.onErr(err)
+		return
+	}
+
+	for _, entry := range entries {
+		name := filepath.Join(dir, entry.Name())
+		mode := entry.Type()
+
+		if mode&fs.ModeSymlink != 0 {
+			if !w.cfg.followLinks {
+				continue
+			}
+			info, err := os.Stat(name)
+			if err != nil {
+				w.onErr(err)
+				continue
+			}
+			mode = info.Mode().Type()
+		}
+
+		isDir := mode.IsDir()
+		if w.cfg.useIgnore && (entry.Name() == ".git" || ignored(rules, name, isDir)) {
+			continue
+		}
+
+		switch {
+		case isDir:
+			if w.cfg.dirSelected(entry.Name()) {
+				w.walk(name, rules)
+			}
+		case mode.IsRegular():
+			if w.cfg.selected(entry.Name()) {
+				w.files = append(w.files, name)
+			}
+		}
+	}
+}
+
+type ignoreRule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func loadIgnoreFile(dir string) ([]ignoreRule, error) {
+	file, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), " \t")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		rule := ignoreRule{
+			base: dir,
+		}
+
+		if strings.HasPrefix(text, "!") {
+			rule.negate = true
+			text = text[1:]
+		} else if strings.HasPrefix(text, `\`) {
+			text = text[1:]
+		}
+
+		if strings.HasSuffix(text, "/") {
+			rule.dirOnly = true
+			text = strings.TrimSuffix(text, "/")
+		}
+
+		if strings.Contains(text, "/") {
+			rule.anchored = true
+			text = strings.TrimPrefix(text, "/")
+		}
+
+		rule.pattern = text
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// ignored applies rules in order; the last matching rule decides, as in git.
+func ignored(rules []ignoreRule, name string, isDir bool) bool {
+	result := false
+
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		rel, err := filepath.Rel(rule.base, name)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		var ok bool
+		if rule.anchored {
+			ok = matchPath(strings.Split(rule.pattern, "/"), strings.Split(rel, "/"))
+		} else {
+			ok, _ = path.Match(rule.pattern, path.Base(rel))
+		}
+
+		if ok {
+			result = !rule.negate
+		}
+	}
+
+	return result
+}
+
+// matchPath matches slash-separated segments, where a "**" segment stands for
+// any number of directories.
+func matchPath(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchPath(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+
+	return matchPath(pattern[1:], segments[1:])
+}
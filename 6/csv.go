@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"unicode/utf8"
+)
+
+// cutCSV selects fields from CSV records. Quoted fields may contain the
+// delimiter or newlines; fields are re-quoted on output when needed.
+func cutCSV(cfg *cutConfig, r io.Reader, w io.Writer) error {
+	reader := csv.NewReader(r)
+	reader.Comma, _ = utf8.DecodeRuneInString(cfg.delimiter)
+	reader.FieldsPerRecord = -1
+
+	writer := csv.NewWriter(w)
+	writer.Comma, _ = utf8.DecodeRuneInString(cfg.outDelimiter)
+
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if first && cfg.header {
+			if err := cfg.readHeader(record); err != nil {
+				return err
+			}
+			if cfg.projection != nil {
+				if err := writer.Write(cfg.projection.names(record)); err != nil {
+					return err
+				}
+				first = false
+				continue
+			}
+		}
+		first = false
+
+		if cfg.separated && len(record) < 2 {
+			continue
+		}
+
+		if err := writer.Write(cfg.pick(record)); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
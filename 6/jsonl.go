@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a dotted path such as user.id or items.0.name; numeric
+// segments index arrays.
+type jsonPath []string
+
+func parsePaths(spec string) ([]jsonPath, error) {
+	var paths []jsonPath
+	for _, item := range strings.Split(spec, ",") {
+		if item == "" {
+			return nil, fmt.Errorf("empty path in %q", spec)
+		}
+		paths = append(paths, strings.Split(item, "."))
+	}
+
+	return paths, nil
+}
+
+// checkNesting rejects paths of which one lies inside another, such as user
+// and user.id: a JSON object cannot hold both the whole user and a part of it.
+func checkNesting(paths []jsonPath) error {
+	for _, outer := range paths {
+		for _, inner := range paths {
+			if len(outer) < len(inner) && outer.prefixOf(inner) {
+				return fmt.Errorf("path %q lies inside %q", strings.Join(inner, "."), strings.Join(outer, "."))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p jsonPath) prefixOf(other jsonPath) bool {
+	for i, key := range p {
+		if other[i] != key {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p jsonPath) lookup(v any) (any, bool) {
+	for _, key := range p {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// orderedObject marshals its keys in insertion order, so output objects keep
+// the order of the -f list.
+type orderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedObject() *orderedObject {
+	return &orderedObject{
+		values: make(map[string]any),
+	}
+}
+
+func (o *orderedObject) set(path jsonPath, v any) {
+	key := path[0]
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+
+	if len(path) == 1 {
+		o.values[key] = v
+		return
+	}
+
+	child, ok := o.values[key].(*orderedObject)
+	if !ok {
+		child = newOrderedObject()
+		o.values[key] = child
+	}
+	child.set(path[1:], v)
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// textValue renders a JSON value for delimited output: strings without
+// quotes, missing values and null as empty, containers as compact JSON.
+func textValue(v any) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// cutJSONL selects dotted paths from JSON Lines records and writes them as
+// delimited text or, with cfg.jsonOutput, as smaller JSON objects.
+func cutJSONL(cfg *cutConfig, r io.Reader, w *bufio.Writer) error {
+	reader := bufio.NewReader(r)
+
+	for num := 1; ; num++ {
+		text, err := reader.ReadString(cfg.terminator)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if text == "" && err == io.EOF {
+			return nil
+		}
+
+		if line := strings.TrimSpace(text); line != "" {
+			if err := cutRecord(cfg, line, w); err != nil {
+				return fmt.Errorf("record %d: %w", num, err)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func cutRecord(cfg *cutConfig, line string, w *bufio.Writer) error {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+
+	var record any
+	if err := decoder.Decode(&record); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("trailing data")
+	}
+
+	values := make([]any, len(cfg.paths))
+	found := false
+	for i, path := range cfg.paths {
+		v, ok := path.lookup(record)
+		values[i] = v
+		found = found || ok
+	}
+
+	if cfg.separated && !found {
+		return nil
+	}
+
+	if cfg.jsonOutput {
+		obj := newOrderedObject()
+		for i, path := range cfg.paths {
+			obj.set(path, values[i])
+		}
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		w.Write(b)
+		w.WriteByte(cfg.terminator)
+		return nil
+	}
+
+	fields := make([]string, len(values))
+	for i, v := range values {
+		s, err := textValue(v)
+		if err != nil {
+			return err
+		}
+		fields[i] = s
+	}
+
+	w.WriteString(strings.Join(fields, cfg.outDelimiter))
+	w.WriteByte(cfg.terminator)
+	return nil
+}
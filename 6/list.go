@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// span is an inclusive 1-based range of positions; hi == 0 means "to the end
+// of the line".
+type span struct {
+	lo, hi int
+}
+
+// positionList is a sorted list of non-overlapping spans built from a POSIX
+// cut list such as "1-3,5,7-" or "-2". As in GNU cut, adjacent spans such as
+// "1,2" stay apart so that the output delimiter goes between them.
+type positionList []span
+
+func parseList(s string) (positionList, error) {
+	return parseNamedList(s, nil)
+}
+
+// parseNamedList is parseList where items may also be column names from
+// header.
+func parseNamedList(s string, header []string) (positionList, error) {
+	if s == "" {
+		return nil, errors.New("list is empty")
+	}
+
+	var list positionList
+	for _, item := range strings.Split(s, ",") {
+		if i := indexOf(header, item); i >= 0 {
+			list = append(list, span{lo: i + 1, hi: i + 1})
+			continue
+		}
+
+		sp, err := parseSpan(item)
+		if err != nil {
+			if header != nil {
+				return nil, fmt.Errorf("no column named %q in header", item)
+			}
+			return nil, err
+		}
+		list = append(list, sp)
+	}
+
+	return list.merge(), nil
+}
+
+func indexOf(header []string, name string) int {
+	for i, column := range header {
+		if column == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func parseSpan(item string) (span, error) {
+	lo, hi, isRange := strings.Cut(item, "-")
+	if !isRange {
+		n, err := parsePosition(item)
+		if err != nil {
+			return span{}, err
+		}
+		return span{lo: n, hi: n}, nil
+	}
+
+	if lo == "" && hi == "" {
+		return span{}, fmt.Errorf("invalid range with no endpoint: %q", item)
+	}
+
+	sp := span{lo: 1}
+	var err error
+
+	if lo != "" {
+		if sp.lo, err = parsePosition(lo); err != nil {
+			return span{}, err
+		}
+	}
+
+	if hi != "" {
+		if sp.hi, err = parsePosition(hi); err != nil {
+			return span{}, err
+		}
+		if sp.hi < sp.lo {
+			return span{}, fmt.Errorf("invalid decreasing range: %q", item)
+		}
+	}
+
+	return sp, nil
+}
+
+func parsePosition(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid field value %q", s)
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("fields and positions are numbered from 1: %q", s)
+	}
+
+	return n, nil
+}
+
+func (l positionList) merge() positionList {
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].lo < l[j].lo
+	})
+
+	merged := l[:0]
+	for _, sp := range l {
+		if len(merged) == 0 {
+			merged = append(merged, sp)
+			continue
+		}
+
+		last := &merged[len(merged)-1]
+		switch {
+		case last.hi == 0:
+		case sp.lo <= last.hi:
+			if sp.hi == 0 || sp.hi > last.hi {
+				last.hi = sp.hi
+			}
+		default:
+			merged = append(merged, sp)
+		}
+	}
+
+	return merged
+}
+
+// contains reports whether the 1-based position i is selected.
+func (l positionList) contains(i int) bool {
+	for _, sp := range l {
+		if i < sp.lo {
+			return false
+		}
+		if sp.hi == 0 || i <= sp.hi {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startsAt reports whether a span starts at the 1-based position i.
+func (l positionList) startsAt(i int) bool {
+	for _, sp := range l {
+		if sp.lo >= i {
+			return sp.lo == i
+		}
+	}
+
+	return false
+}
@@ -1,75 +1,402 @@
-package main
-
-import (
-	"bufio"
-	"flag"
-	"fmt"
-	"log"
-	"os"
-	"strconv"
-	"strings"
-)
-
-func main() {
-	fields := flag.String("f", "-1", "Select fields (columns)")
-	delimiter := flag.String("d", "\t", "Use different delimiter")
-	separated := flag.Bool("s", false, "Only separated strings")
-
-	flag.Parse()
-
-	f, err := getFields(*fields)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	cut(f, *delimiter, *separated)
-}
-
-/*
-	пример:
-	```
-		echo -e "1, 2, 3\n4, 5, 6\n" | go run main.go -f 1,3 -d ","
-	```
-*/
-
-func getFields(f string) ([]int, error) {
-	var res []int
-	split := strings.Split(f, ",")
-
-	for _, str := range split {
-		num, err := strconv.Atoi(str)
-		if err != nil {
-			return nil, err
-		}
-
-		res = append(res, num)
-	}
-
-	return res, nil
-}
-
-func cut(fields []int, delimiter string, separated bool) {
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Split(line, delimiter)
-		if separated && !strings.Contains(line, delimiter) {
-			continue
-		}
-		var selectedFields []string
-		for _, field := range fields {
-			if field < 0 {
-				selectedFields = append(selectedFields, line)
-				continue
-			}
-			if field <= len(parts) {
-				selectedFields = append(selectedFields, parts[field-1])
-			}
-		}
-		fmt.Println(strings.Join(selectedFields, delimiter))
-	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintln(os.Stderr, "error reading standard input:", err)
-	}
-}
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+type cutMode int
+
+const (
+	modeFields cutMode = iota
+	modeBytes
+	modeChars
+)
+
+type cutConfig struct {
+	mode         cutMode
+	spec         string
+	list         positionList
+	projection   projection
+	complement   bool
+	delimiter    string
+	split        splitter
+	outDelimiter string
+	separated    bool
+	header       bool
+	csv          bool
+	jsonl        bool
+	paths        []jsonPath
+	jsonOutput   bool
+	terminator   byte
+}
+
+func main() {
+	fields := flag.String("f", "", "Select only these `LIST` of fields")
+	bytes := flag.String("b", "", "Select only these `LIST` of bytes")
+	chars := flag.String("c", "", "Select only these `LIST` of characters")
+	project := flag.String("F", "", "Print the fields, literals and transforms of `PROJECTION` in that order")
+	delimiter := flag.String("d", "\t", "Use different delimiter")
+	separated := flag.Bool("s", false, "Only separated strings")
+	complement := flag.Bool("complement", false, "Select everything except the LIST")
+	outDelimiter := flag.String("output-delimiter", "", "Use `STRING` as the output delimiter (default is the input delimiter)")
+	csvMode := flag.Bool("csv", false, "Parse input as CSV with quoted fields (delimiter defaults to ',')")
+	tsvMode := flag.Bool("tsv", false, "Parse input as quoted TSV, same as --csv -d '\\t'")
+	header := flag.Bool("header", false, "Treat the first line as a header; fields may be selected by column name")
+	regex := flag.Bool("regex", false, "Interpret the -d delimiter as a regular expression")
+	whitespace := flag.Bool("w", false, "Use runs of whitespace as the delimiter, ignoring leading blanks")
+	zeroTerminated := flag.Bool("z", false, "Line delimiter is NUL, not newline")
+	jsonl := flag.Bool("jsonl", false, "Parse input as JSON Lines; -f takes dotted paths such as user.id")
+	jsonOutput := flag.Bool("json-output", false, "With --jsonl, print the selected paths as JSON objects")
+
+	flag.Parse()
+
+	cfg, err := newCutConfig(*fields, *bytes, *chars, *project, *header || *jsonl)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg.delimiter = *delimiter
+	cfg.separated = *separated
+	cfg.complement = *complement
+	cfg.header = *header
+	cfg.csv = *csvMode || *tsvMode
+	cfg.jsonl = *jsonl
+	cfg.jsonOutput = *jsonOutput
+	cfg.terminator = '\n'
+	if *zeroTerminated {
+		cfg.terminator = 0
+	}
+	if *csvMode {
+		cfg.delimiter = ","
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "d":
+			cfg.delimiter = *delimiter
+			if cfg.mode != modeFields {
+				err = fmt.Errorf("-%s is only meaningful when operating on fields", f.Name)
+			}
+		case "s":
+			if cfg.mode != modeFields {
+				err = fmt.Errorf("-%s is only meaningful when operating on fields", f.Name)
+			}
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg.split = literalSplitter(cfg.delimiter)
+	cfg.outDelimiter = cfg.delimiter
+	switch {
+	case cfg.mode != modeFields:
+		cfg.outDelimiter = ""
+	case *regex:
+		cfg.split, err = regexSplitter(cfg.delimiter)
+		if err != nil {
+			log.Fatal("invalid delimiter: ", err)
+		}
+		cfg.outDelimiter = " "
+	case *whitespace:
+		cfg.split = whitespaceSplitter()
+		cfg.outDelimiter = " "
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "output-delimiter" {
+			cfg.outDelimiter = *outDelimiter
+		}
+	})
+
+	switch {
+	case cfg.csv && cfg.mode != modeFields:
+		log.Fatal("--csv and --tsv work only with -f")
+	case cfg.csv && *csvMode && *tsvMode:
+		log.Fatal("--csv and --tsv are mutually exclusive")
+	case cfg.csv && utf8.RuneCountInString(cfg.delimiter) != 1:
+		log.Fatal("the delimiter must be a single character in CSV mode")
+	case cfg.csv && utf8.RuneCountInString(cfg.outDelimiter) != 1:
+		log.Fatal("the output delimiter must be a single character in CSV mode")
+	case cfg.header && cfg.mode != modeFields:
+		log.Fatal("--header works only with -f")
+	case (*regex || *whitespace) && (cfg.mode != modeFields || cfg.csv):
+		log.Fatal("--regex and -w work only with -f on plain text")
+	case *regex && *whitespace:
+		log.Fatal("--regex and -w are mutually exclusive")
+	case cfg.csv && *zeroTerminated:
+		log.Fatal("-z cannot be combined with --csv or --tsv")
+	case cfg.projection != nil && cfg.complement:
+		log.Fatal("-F cannot be combined with --complement")
+	case cfg.jsonOutput && !cfg.jsonl:
+		log.Fatal("--json-output works only with --jsonl")
+	case cfg.jsonl && (cfg.mode != modeFields || cfg.projection != nil):
+		log.Fatal("--jsonl works only with -f")
+	case cfg.jsonl && (cfg.csv || cfg.header || cfg.complement || *regex || *whitespace):
+		log.Fatal("--jsonl cannot be combined with --csv, --tsv, --header, --complement, --regex or -w")
+	}
+
+	if cfg.jsonl {
+		cfg.paths, err = parsePaths(cfg.spec)
+		if err == nil && cfg.jsonOutput {
+			err = checkNesting(cfg.paths)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	operands := flag.Args()
+	if len(operands) == 0 {
+		operands = []string{"-"}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	failed := false
+	for _, name := range operands {
+		if err := cutFile(cfg, name, out); err != nil {
+			out.Flush()
+			log.Println(err)
+			failed = true
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+/*
+	пример:
+	```
+		echo -e "1, 2, 3\n4, 5, 6\n" | go run . -f 1,3 -d ","
+		echo -e "1:2:3:4:5" | go run . -f 2- -d ":" --output-delimiter " "
+		echo "привет" | go run . -c 1-3
+		ps aux | go run . -w -f 2,11
+		echo "a1b22c" | go run . --regex -d "[0-9]+" -f 1,3
+		echo '{"user":{"id":7},"status":"ok"}' | go run . --jsonl -f user.id,status
+		echo '{"user":{"id":7},"status":"ok"}' | go run . --jsonl --json-output -f user.id
+	```
+*/
+
+func newCutConfig(fields, bytes, chars, project string, named bool) (*cutConfig, error) {
+	cfg := &cutConfig{}
+	set := 0
+	list := ""
+
+	if fields != "" {
+		set++
+		cfg.mode, list = modeFields, fields
+	}
+	if bytes != "" {
+		set++
+		cfg.mode, list = modeBytes, bytes
+	}
+	if chars != "" {
+		set++
+		cfg.mode, list = modeChars, chars
+	}
+	if project != "" {
+		set++
+		cfg.mode = modeFields
+	}
+
+	switch set {
+	case 0:
+		return nil, errors.New("you must specify a list of bytes, characters, or fields")
+	case 1:
+	default:
+		return nil, errors.New("only one type of list may be specified")
+	}
+
+	if project != "" {
+		p, err := parseProjection(project)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.validate(named); err != nil {
+			return nil, err
+		}
+		cfg.projection = p
+		return cfg, nil
+	}
+
+	cfg.spec = list
+
+	// Column names can only be resolved once the header has been read, and
+	// --jsonl lists are paths rather than positions.
+	if named {
+		return cfg, nil
+	}
+
+	var err error
+	cfg.list, err = parseList(list)
+	if err != nil {
+		return nil, fmt.Errorf("invalid list %q: %w", list, err)
+	}
+
+	return cfg, nil
+}
+
+func (cfg *cutConfig) readHeader(columns []string) error {
+	if cfg.projection != nil {
+		return cfg.projection.resolve(columns)
+	}
+
+	list, err := parseNamedList(cfg.spec, columns)
+	if err != nil {
+		return err
+	}
+
+	cfg.list = list
+	return nil
+}
+
+func (cfg *cutConfig) selected(i int) bool {
+	return cfg.list.contains(i) != cfg.complement
+}
+
+// cutLine returns the selected part of line and false if the line must be
+// skipped altogether.
+func (cfg *cutConfig) cutLine(line string) (string, bool) {
+	switch cfg.mode {
+	case modeBytes:
+		return cfg.join(len(line), func(i int) string {
+			return line[i : i+1]
+		}), true
+
+	case modeChars:
+		runes := make([]string, 0, utf8.RuneCountInString(line))
+		for _, r := range line {
+			runes = append(runes, string(r))
+		}
+		return cfg.join(len(runes), func(i int) string {
+			return runes[i]
+		}), true
+	}
+
+	parts := cfg.split(line)
+	if len(parts) < 2 && (cfg.separated || cfg.projection == nil) {
+		return line, !cfg.separated
+	}
+
+	return strings.Join(cfg.pick(parts), cfg.outDelimiter), true
+}
+
+// pick returns the output fields of a record: the projection if one is set,
+// otherwise the selected fields in input order.
+func (cfg *cutConfig) pick(fields []string) []string {
+	if cfg.projection != nil {
+		return cfg.projection.apply(fields)
+	}
+
+	selectedFields := make([]string, 0, len(fields))
+	for i, field := range fields {
+		if cfg.selected(i + 1) {
+			selectedFields = append(selectedFields, field)
+		}
+	}
+
+	return selectedFields
+}
+
+// join concatenates the selected bytes or characters in input order, putting
+// the output delimiter between the spans of the list, or with --complement
+// between the runs it leaves.
+func (cfg *cutConfig) join(n int, at func(int) string) string {
+	var b strings.Builder
+	last := -1
+
+	for i := 0; i < n; i++ {
+		if !cfg.selected(i + 1) {
+			continue
+		}
+		if last >= 0 && (last != i-1 || !cfg.complement && cfg.list.startsAt(i+1)) {
+			b.WriteString(cfg.outDelimiter)
+		}
+		b.WriteString(at(i))
+		last = i
+	}
+
+	return b.String()
+}
+
+func cutFile(cfg *cutConfig, name string, w *bufio.Writer) error {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var err error
+	switch {
+	case cfg.jsonl:
+		err = cutJSONL(cfg, r, w)
+	case cfg.csv:
+		err = cutCSV(cfg, r, w)
+	default:
+		err = cut(cfg, r, w)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// cut reads records of any length terminated by cfg.terminator and writes
+// the selected part of each to w.
+func cut(cfg *cutConfig, r io.Reader, w *bufio.Writer) error {
+	reader := bufio.NewReader(r)
+	first := true
+
+	for {
+		text, err := reader.ReadString(cfg.terminator)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if text == "" && err == io.EOF {
+			return nil
+		}
+		text = strings.TrimSuffix(text, string(cfg.terminator))
+
+		if first && cfg.header {
+			columns := cfg.split(text)
+			if err := cfg.readHeader(columns); err != nil {
+				return err
+			}
+			// The header row is named, not projected: literals and
+			// transforms apply to data only.
+			if cfg.projection != nil {
+				w.WriteString(strings.Join(cfg.projection.names(columns), cfg.outDelimiter))
+				w.WriteByte(cfg.terminator)
+				first = false
+				continue
+			}
+		}
+		first = false
+
+		if line, ok := cfg.cutLine(text); ok {
+			w.WriteString(line)
+			w.WriteByte(cfg.terminator)
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
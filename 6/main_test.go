@@ -0,0 +1,488 @@
+package main
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		list string
+		want positionList
+	}{
+		{"1", positionList{{1, 1}}},
+		{"3,1", positionList{{1, 1}, {3, 3}}},
+		{"1-3,2-5", positionList{{1, 5}}},
+		{"1,2", positionList{{1, 1}, {2, 2}}},
+		{"-2,4-", positionList{{1, 2}, {4, 0}}},
+		{"2-,5", positionList{{2, 0}}},
+		{"1,1", positionList{{1, 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := parseList(tt.list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListErrors(t *testing.T) {
+	tests := []struct {
+		list string
+		want string
+	}{
+		{"", "list is empty"},
+		{"0", "numbered from 1"},
+		{"1,0-2", "numbered from 1"},
+		{"a", `invalid field value "a"`},
+		{"1,,2", `invalid field value ""`},
+		{"-", "no endpoint"},
+		{"3-1", "decreasing range"},
+		{"1-x", `invalid field value "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			_, err := parseList(tt.list)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCutConfigErrors(t *testing.T) {
+	tests := []struct {
+		name                          string
+		fields, bytes, chars, project string
+		want                          string
+	}{
+		{"no list", "", "", "", "", "must specify a list"},
+		{"two lists", "1", "2", "", "", "only one type of list"},
+		{"list and projection", "1", "", "", "2", "only one type of list"},
+		{"bad list", "0", "", "", "", `invalid list "0"`},
+		{"name without header", "", "", "", "user", "requires --header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newCutConfig(tt.fields, tt.bytes, tt.chars, tt.project, false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// testConfig builds the configuration of cut -f, -b or -c with the given
+// list and the default delimiters.
+func testConfig(t *testing.T, mode cutMode, list string) *cutConfig {
+	t.Helper()
+
+	var cfg *cutConfig
+	var err error
+	switch mode {
+	case modeBytes:
+		cfg, err = newCutConfig("", list, "", "", false)
+	case modeChars:
+		cfg, err = newCutConfig("", "", list, "", false)
+	default:
+		cfg, err = newCutConfig(list, "", "", "", false)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.delimiter = "\t"
+	cfg.split = literalSplitter(cfg.delimiter)
+	if mode == modeFields {
+		cfg.outDelimiter = cfg.delimiter
+	}
+	cfg.terminator = '\n'
+	return cfg
+}
+
+func TestCutLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       cutMode
+		list       string
+		complement bool
+		separated  bool
+		outDelim   string
+		line       string
+		want       string
+		ok         bool
+	}{
+		{"fields", modeFields, "1,3", false, false, "", "a\tb\tc\td", "a\tc", true},
+		{"fields past the end", modeFields, "2,9", false, false, "", "a\tb", "b", true},
+		{"open range", modeFields, "2-", false, false, "", "a\tb\tc", "b\tc", true},
+		{"complement fields", modeFields, "2", true, false, "", "a\tb\tc", "a\tc", true},
+		{"output delimiter", modeFields, "1,3", false, false, ":", "a\tb\tc", "a:c", true},
+		{"unseparated kept", modeFields, "2", false, false, "", "abc", "abc", true},
+		{"unseparated skipped", modeFields, "2", false, true, "", "abc", "", false},
+		{"bytes", modeBytes, "1-2,4", false, false, "", "abcde", "abd", true},
+		{"bytes split a rune", modeBytes, "1", false, false, "", "ё", "\xd1", true},
+		{"complement bytes", modeBytes, "2-3", true, false, "", "abcde", "ade", true},
+		{"chars", modeChars, "2-3", false, false, "", "привет", "ри", true},
+		{"complement chars", modeChars, "1,6", true, false, "", "привет", "риве", true},
+		{"chars delimited ranges", modeChars, "1,2,4-", false, false, ":", "abcdef", "a:b:def", true},
+		{"chars delimited adjacent ranges", modeChars, "1-2,3", false, false, ":", "abcd", "ab:c", true},
+		{"chars delimited complement", modeChars, "1,3", true, false, ":", "abcde", "b:de", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, tt.mode, tt.list)
+			cfg.complement = tt.complement
+			cfg.separated = tt.separated
+			if tt.outDelim != "" {
+				cfg.outDelimiter = tt.outDelim
+			}
+
+			got, ok := cfg.cutLine(tt.line)
+			if ok != tt.ok || ok && got != tt.want {
+				t.Fatalf("got %q, %v; want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCutRecords(t *testing.T) {
+	long := strings.Repeat("x", 200<<10)
+
+	tests := []struct {
+		name       string
+		list       string
+		terminator byte
+		header     bool
+		input      string
+		want       string
+	}{
+		{"lines", "2", '\n', false, "a\tb\nc\td\n", "b\nd\n"},
+		{"no final newline", "2", '\n', false, "a\tb\nc\td", "b\nd\n"},
+		{"long line", "2", '\n', false, "a\t" + long + "\tc\n", long + "\n"},
+		{"NUL terminated", "1", 0, false, "a\tb\nc\x00d\te\x00", "a\x00d\x00"},
+		{"header names", "b,a", '\n', true, "a\tb\n1\t2\n", "a\tb\n1\t2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, modeFields, "1")
+			cfg.spec = tt.list
+			cfg.list = nil
+			cfg.header = tt.header
+			cfg.terminator = tt.terminator
+			if !tt.header {
+				var err error
+				if cfg.list, err = parseList(tt.list); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out strings.Builder
+			w := bufio.NewWriter(&out)
+			if err := cut(cfg, strings.NewReader(tt.input), w); err != nil {
+				t.Fatal(err)
+			}
+			w.Flush()
+
+			if out.String() != tt.want {
+				t.Fatalf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCutFileMissing(t *testing.T) {
+	cfg := testConfig(t, modeFields, "1")
+	err := cutFile(cfg, "testdata/no-such-file", bufio.NewWriter(&strings.Builder{}))
+	if err == nil || !strings.Contains(err.Error(), "no-such-file") {
+		t.Fatalf("got error %v, want one naming the file", err)
+	}
+}
+
+func TestCutCSV(t *testing.T) {
+	tests := []struct {
+		name   string
+		list   string
+		delim  string
+		header bool
+		input  string
+		want   string
+	}{
+		{"quoted delimiter", "2", ",", false, "1,\"a,b\",3\n", "\"a,b\"\n"},
+		{"quoted newline", "1,3", ",", false, "\"x\ny\",2,3\n", "\"x\ny\",3\n"},
+		{"escaped quote", "1", ",", false, "\"say \"\"hi\"\"\",2\n", "\"say \"\"hi\"\"\"\n"},
+		{"plain fields unquoted", "2,1", ",", false, "a,b\n", "a,b\n"},
+		{"tsv", "2", "\t", false, "a\t\"b\tc\"\n", "\"b\tc\"\n"},
+		{"header names", "name", ",", true, "id,name\n1,\"Doe, J\"\n", "name\n\"Doe, J\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, modeFields, "1")
+			cfg.csv = true
+			cfg.delimiter, cfg.outDelimiter = tt.delim, tt.delim
+			cfg.header = tt.header
+			cfg.spec = tt.list
+			if !tt.header {
+				var err error
+				if cfg.list, err = parseList(tt.list); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out strings.Builder
+			if err := cutCSV(cfg, strings.NewReader(tt.input), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestProjection(t *testing.T) {
+	fields := []string{" Alice ", "42", "alice@example.com"}
+
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"3,1,1", []string{"alice@example.com", " Alice ", " Alice "}},
+		{`2,"=",'x'`, []string{"42", "=", "x"}},
+		{"1|trim|upper", []string{"ALICE"}},
+		{"1|lower", []string{" alice "}},
+		{"3|substr(1,5)", []string{"alice"}},
+		{"3|substr(7)", []string{"example.com"}},
+		{"3|substr(99)", []string{""}},
+		{`3|replace("@.*","")`, []string{"alice"}},
+		{`3|replace("(\\w+)@(\\w+)","$2:$1")`, []string{"example:alice.com"}},
+		{`"a,b|c"`, []string{"a,b|c"}},
+		{"9", []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			p, err := parseProjection(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.apply(fields); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectionErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"0", "numbered from 1"},
+		{"1,,2", "empty field"},
+		{"1|shout", `unknown transform "shout"`},
+		{"1|upper(1)", "wrong number of arguments"},
+		{"1|substr(0)", "invalid start"},
+		{"1|substr(1,-1)", "invalid length"},
+		{`1|replace("(")`, "wrong number of arguments"},
+		{`1|replace("(","")`, "missing closing )"},
+		{`"abc`, "unterminated quote"},
+		{"1|substr(1", "unbalanced ("},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := parseProjection(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectionResolve(t *testing.T) {
+	p, err := parseProjection("name|upper,id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.resolve([]string{"id", "name"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.apply([]string{"7", "bob"}); !reflect.DeepEqual(got, []string{"BOB", "7"}) {
+		t.Fatalf("got %q", got)
+	}
+
+	if err := p.resolve([]string{"id"}); err == nil {
+		t.Fatal("expected an error for a missing column")
+	}
+}
+
+func TestProjectionHeader(t *testing.T) {
+	cfg, err := newCutConfig("", "", "", `h2|upper,"-",h1`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.header = true
+	cfg.split = whitespaceSplitter()
+	cfg.outDelimiter = " "
+	cfg.terminator = '\n'
+
+	var out strings.Builder
+	w := bufio.NewWriter(&out)
+	if err := cut(cfg, strings.NewReader("h1 h2\na b\n"), w); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if want := "h2 - h1\nB - a\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+
+	cfg, err = newCutConfig("", "", "", `h2|upper,h1`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.header = true
+	cfg.delimiter, cfg.outDelimiter = ",", ","
+
+	out.Reset()
+	if err := cutCSV(cfg, strings.NewReader("h1,h2\na,b\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "h2,h1\nB,a\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestJSONPathLookup(t *testing.T) {
+	record := map[string]any{
+		"status": "ok",
+		"user":   map[string]any{"id": 7.0, "tags": []any{"a", "b"}},
+		"empty":  nil,
+	}
+
+	tests := []struct {
+		path  string
+		want  any
+		found bool
+	}{
+		{"status", "ok", true},
+		{"user.id", 7.0, true},
+		{"user.tags.1", "b", true},
+		{"empty", nil, true},
+		{"user.name", nil, false},
+		{"user.tags.2", nil, false},
+		{"user.tags.x", nil, false},
+		{"user.tags.-1", nil, false},
+		{"status.len", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			paths, err := parsePaths(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, found := paths[0].lookup(record)
+			if !reflect.DeepEqual(got, tt.want) || found != tt.found {
+				t.Fatalf("got %v, %v; want %v, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "a,,b", "a,"} {
+		if _, err := parsePaths(spec); err == nil {
+			t.Errorf("parsePaths(%q): expected an error", spec)
+		}
+	}
+}
+
+func TestCutJSONL(t *testing.T) {
+	tests := []struct {
+		name       string
+		paths      string
+		jsonOutput bool
+		separated  bool
+		input      string
+		want       string
+		err        string
+	}{
+		{"text", "user.id,status", false, false, `{"user":{"id":7},"status":"ok"}` + "\n", "7\tok\n", ""},
+		{"missing and null", "a,b", false, false, `{"b":null}` + "\n", "\t\n", ""},
+		{"containers", "a", false, false, `{"a":{"x":[1,2]}}` + "\n", `{"x":[1,2]}` + "\n", ""},
+		{"big number", "n", false, false, `{"n":12345678901234567890}` + "\n", "12345678901234567890\n", ""},
+		{"skip records without paths", "a", false, true, `{"b":1}` + "\n" + `{"a":2}` + "\n", "2\n", ""},
+		{"blank lines", "a", false, false, "\n" + `{"a":1}` + "\n\n", "1\n", ""},
+		{"json output", "user.id,user.name,status", true, false, `{"status":"ok","user":{"name":"x","id":1}}` + "\n", `{"user":{"id":1,"name":"x"},"status":"ok"}` + "\n", ""},
+		{"json output missing", "a.b", true, false, "{}\n", `{"a":{"b":null}}` + "\n", ""},
+		{"trailing data", "a", false, false, `{"a":1}` + "\n" + `{"a":2} junk` + "\n", "1\n", "record 2: trailing data"},
+		{"trailing brace", "a", false, false, `{"a":1}}` + "\n", "", "record 1: trailing data"},
+		{"invalid", "a", false, false, "{\n", "", "record 1:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := parsePaths(tt.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &cutConfig{
+				jsonl:        true,
+				paths:        paths,
+				jsonOutput:   tt.jsonOutput,
+				separated:    tt.separated,
+				outDelimiter: "\t",
+				terminator:   '\n',
+			}
+
+			var out strings.Builder
+			w := bufio.NewWriter(&out)
+			err = cutJSONL(cfg, strings.NewReader(tt.input), w)
+			w.Flush()
+
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNesting(t *testing.T) {
+	tests := []struct {
+		paths string
+		ok    bool
+	}{
+		{"user.id,user.name", true},
+		{"user.id,user.id", true},
+		{"user,username", true},
+		{"user,user.id", false},
+		{"user.id,user", false},
+		{"items.0.name,items", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.paths, func(t *testing.T) {
+			paths, err := parsePaths(tt.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := checkNesting(paths); (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
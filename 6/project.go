@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+	Проекция (-F) — упрощённая замена `awk '{print $3,$1}'`.
+	Элементы списка перечисляются через запятую и выводятся в заданном порядке:
+
+		3            поле по номеру (или по имени колонки с --header)
+		"text"       строка-литерал
+		2|upper      поле с цепочкой преобразований через |
+
+	Преобразования: trim, upper, lower, substr(START[,LEN]) (с 1, в символах),
+	replace("REGEX","REPL") ($1 в REPL — группа).
+
+	пример:
+	```
+		ps aux | go run . -w -F '11|lower,"pid=",2,2|substr(1,2)'
+	```
+*/
+
+type column struct {
+	field      int
+	name       string
+	literal    string
+	isLiteral  bool
+	transforms []func(string) string
+}
+
+type projection []column
+
+func parseProjection(spec string) (projection, error) {
+	items, err := splitTopLevel(spec, ',')
+	if err != nil {
+		return nil, err
+	}
+
+	var p projection
+	for _, item := range items {
+		col, err := parseColumn(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid projection item %q: %w", item, err)
+		}
+		p = append(p, col)
+	}
+
+	return p, nil
+}
+
+func parseColumn(item string) (column, error) {
+	parts, err := splitTopLevel(item, '|')
+	if err != nil {
+		return column{}, err
+	}
+
+	var col column
+	source := strings.TrimSpace(parts[0])
+
+	switch {
+	case source == "":
+		return column{}, errors.New("empty field")
+	case isQuoted(source):
+		col.isLiteral = true
+		col.literal, err = strconv.Unquote(toDoubleQuoted(source))
+		if err != nil {
+			return column{}, err
+		}
+	default:
+		n, err := strconv.Atoi(source)
+		switch {
+		case err != nil:
+			col.name = source
+		case n < 1:
+			return column{}, errors.New("fields are numbered from 1")
+		default:
+			col.field = n
+		}
+	}
+
+	for _, part := range parts[1:] {
+		fn, err := parseTransform(strings.TrimSpace(part))
+		if err != nil {
+			return column{}, err
+		}
+		col.transforms = append(col.transforms, fn)
+	}
+
+	return col, nil
+}
+
+func parseTransform(s string) (func(string) string, error) {
+	name, args := s, []string(nil)
+	if open := strings.IndexByte(s, '('); open >= 0 {
+		if !strings.HasSuffix(s, ")") {
+			return nil, fmt.Errorf("missing ) in %q", s)
+		}
+		name = s[:open]
+
+		raw, err := splitTopLevel(s[open+1:len(s)-1], ',')
+		if err != nil {
+			return nil, err
+		}
+		for _, arg := range raw {
+			arg = strings.TrimSpace(arg)
+			if isQuoted(arg) {
+				arg, err = strconv.Unquote(toDoubleQuoted(arg))
+				if err != nil {
+					return nil, err
+				}
+			}
+			args = append(args, arg)
+		}
+	}
+
+	switch name {
+	case "trim":
+		return strings.TrimSpace, checkArgs(name, args, 0, 0)
+	case "upper":
+		return strings.ToUpper, checkArgs(name, args, 0, 0)
+	case "lower":
+		return strings.ToLower, checkArgs(name, args, 0, 0)
+	case "substr":
+		return substrTransform(args)
+	case "replace":
+		if err := checkArgs(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			return nil, err
+		}
+		repl := args[1]
+		return func(s string) string {
+			return re.ReplaceAllString(s, repl)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown transform %q", name)
+}
+
+func substrTransform(args []string) (func(string) string, error) {
+	if err := checkArgs("substr", args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	start, err := strconv.Atoi(args[0])
+	if err != nil || start < 1 {
+		return nil, fmt.Errorf("substr: invalid start %q", args[0])
+	}
+
+	length := -1
+	if len(args) == 2 {
+		length, err = strconv.Atoi(args[1])
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("substr: invalid length %q", args[1])
+		}
+	}
+
+	return func(s string) string {
+		runes := []rune(s)
+		if start > len(runes) {
+			return ""
+		}
+		runes = runes[start-1:]
+		if length >= 0 && length < len(runes) {
+			runes = runes[:length]
+		}
+		return string(runes)
+	}, nil
+}
+
+func checkArgs(name string, args []string, lo, hi int) error {
+	if len(args) < lo || len(args) > hi {
+		return fmt.Errorf("%s: wrong number of arguments", name)
+	}
+
+	return nil
+}
+
+// resolve replaces column names with field numbers from header.
+func (p projection) resolve(header []string) error {
+	for i := range p {
+		if p[i].isLiteral || p[i].name == "" {
+			continue
+		}
+
+		idx := indexOf(header, p[i].name)
+		if idx < 0 {
+			return fmt.Errorf("no column named %q in header", p[i].name)
+		}
+		p[i].field = idx + 1
+	}
+
+	return nil
+}
+
+func (p projection) validate(header bool) error {
+	for _, col := range p {
+		if col.name != "" && !header {
+			return fmt.Errorf("column name %q requires --header", col.name)
+		}
+	}
+
+	return nil
+}
+
+// names builds the output header from the header row: fields give their
+// column names and literals stay as written; transforms are not applied.
+func (p projection) names(header []string) []string {
+	out := make([]string, 0, len(p))
+
+	for _, col := range p {
+		name := col.literal
+		if !col.isLiteral && col.field <= len(header) {
+			name = header[col.field-1]
+		}
+		out = append(out, name)
+	}
+
+	return out
+}
+
+// apply builds the output fields; fields missing from the record are empty.
+func (p projection) apply(fields []string) []string {
+	out := make([]string, 0, len(p))
+
+	for _, col := range p {
+		value := col.literal
+		if !col.isLiteral && col.field <= len(fields) {
+			value = fields[col.field-1]
+		}
+		for _, fn := range col.transforms {
+			value = fn(value)
+		}
+		out = append(out, value)
+	}
+
+	return out
+}
+
+// splitTopLevel splits s on sep outside of quotes and parentheses.
+func splitTopLevel(s string, sep rune) ([]string, error) {
+	var parts []string
+	var quote rune
+	depth := 0
+	start := 0
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				i += size
+				_, size = utf8.DecodeRuneInString(s[i:])
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("unbalanced )")
+			}
+		case r == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + size
+		}
+
+		i += size
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced (")
+	}
+
+	return append(parts, s[start:]), nil
+}
+
+func isQuoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0]
+}
+
+// toDoubleQuoted rewrites a single-quoted literal so strconv.Unquote accepts
+// it as a Go string.
+func toDoubleQuoted(s string) string {
+	if s[0] == '"' {
+		return s
+	}
+
+	inner := strings.ReplaceAll(s[1:len(s)-1], `"`, `\"`)
+	inner = strings.ReplaceAll(inner, `\'`, `'`)
+	return `"` + inner + `"`
+}
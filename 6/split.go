@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// splitter breaks a line into fields. A line without any delimiter yields a
+// single field.
+type splitter func(line string) []string
+
+func literalSplitter(delimiter string) splitter {
+	return func(line string) []string {
+		return strings.Split(line, delimiter)
+	}
+}
+
+func regexSplitter(expr string) (splitter, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if re.MatchString("") {
+		return nil, errors.New("delimiter regex must not match the empty string")
+	}
+
+	return func(line string) []string {
+		return re.Split(line, -1)
+	}, nil
+}
+
+// whitespaceSplitter treats each run of blanks as one delimiter and ignores
+// leading and trailing blanks, as needed for ps or df output.
+func whitespaceSplitter() splitter {
+	return strings.Fields
+}
@@ -0,0 +1,215 @@
+// Package combine provides generic channel combinators for pipelines. Every
+// goroutine started here exits once its inputs are drained or ctx is done, so
+// cancelling the context is enough to tear a pipeline down. Done-signals are
+// joined with the or package.
+package combine
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Cagge/lvl2/7/or"
+)
+
+// ErrClosed is returned by FirstOf when every input closes without a value.
+var ErrClosed = errors.New("combine: all channels closed")
+
+// OrDone forwards values from in until in closes or ctx is done, so a range
+// over the result never blocks on an abandoned input.
+func OrDone[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok || !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Merge fans in the values of all inputs; the result closes when every input
+// has closed or ctx is done. Values from one input keep their order.
+func Merge[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+
+	wg.Add(len(ins))
+	for _, in := range ins {
+		go func() {
+			defer wg.Done()
+			for v := range OrDone(ctx, in) {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Tee delivers every value of in to both results. A value is sent to the
+// second result only after the first has taken it, in either order, so the
+// slower reader sets the pace.
+func Tee[T any](ctx context.Context, in <-chan T) (<-chan T, <-chan T) {
+	out1 := make(chan T)
+	out2 := make(chan T)
+
+	go func() {
+		defer close(out1)
+		defer close(out2)
+
+		for v := range OrDone(ctx, in) {
+			// Local copies are set to nil once served so that each value
+			// goes to each output exactly once.
+			o1, o2 := out1, out2
+			for o1 != nil || o2 != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case o1 <- v:
+					o1 = nil
+				case o2 <- v:
+					o2 = nil
+				}
+			}
+		}
+	}()
+
+	return out1, out2
+}
+
+// Bridge flattens a channel of channels into a single stream, reading each
+// inner channel to the end before taking the next one.
+func Bridge[T any](ctx context.Context, chans <-chan (<-chan T)) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for in := range OrDone(ctx, chans) {
+			for v := range OrDone(ctx, in) {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Take forwards at most n values from in.
+func Take[T any](ctx context.Context, in <-chan T, n int) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for i := 0; i < n; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok || !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Repeat sends values in a loop until ctx is done. With no values the result
+// is closed immediately.
+func Repeat[T any](ctx context.Context, values ...T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		if len(values) == 0 {
+			return
+		}
+		for {
+			for _, v := range values {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// FirstOf returns the first value delivered by any input. It fails with
+// ctx.Err() when ctx is done first and with ErrClosed when all inputs close
+// without a value.
+func FirstOf[T any](ctx context.Context, ins ...<-chan T) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var zero T
+	select {
+	case v, ok := <-Merge(ctx, ins...):
+		if !ok {
+			if err := ctx.Err(); err != nil {
+				return zero, err
+			}
+			return zero, ErrClosed
+		}
+		return v, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// All is the counterpart of or.Or: the result closes only when every input
+// has closed, or as soon as ctx is done. Values are drained and discarded.
+func All[T any](ctx context.Context, ins ...<-chan T) <-chan struct{} {
+	return Done(ctx, drain(ctx, ins...))
+}
+
+// Done returns a channel that is closed when ctx is done or any of signals
+// fires, joined with or.Or.
+func Done(ctx context.Context, signals ...<-chan struct{}) <-chan struct{} {
+	all := make([]<-chan struct{}, 0, len(signals)+1)
+	all = append(all, signals...)
+	return or.Or(append(all, ctx.Done())...)
+}
+
+// drain closes its result once every input has closed.
+func drain[T any](ctx context.Context, ins ...<-chan T) <-chan struct{} {
+	drained := make(chan struct{})
+
+	go func() {
+		defer close(drained)
+		for range Merge(ctx, ins...) {
+		}
+	}()
+
+	return drained
+}
+
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- v:
+		return true
+	}
+}
@@ -0,0 +1,198 @@
+package combine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+func from[T any](values ...T) <-chan T {
+	ch := make(chan T, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+
+	return ch
+}
+
+func collect[T any](t *testing.T, ch <-chan T) []T {
+	t.Helper()
+
+	var out []T
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return out
+			}
+			out = append(out, v)
+		case <-timeout:
+			t.Fatal("channel did not close")
+		}
+	}
+}
+
+func checkGoroutines(t *testing.T, base int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d running, want %d", runtime.NumGoroutine(), base)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestOrDone(t *testing.T) {
+	ctx := context.Background()
+	if got := collect(t, OrDone(ctx, from(1, 2, 3))); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v", got)
+	}
+
+	base := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(ctx)
+	out := OrDone(ctx, make(chan int))
+	cancel()
+	collect(t, out)
+	checkGoroutines(t, base)
+}
+
+func TestMerge(t *testing.T) {
+	got := collect(t, Merge(context.Background(), from(1, 2), from(3), from[int]()))
+	sort.Ints(got)
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestTee(t *testing.T) {
+	a, b := Tee(context.Background(), from("x", "y"))
+
+	var got1, got2 []string
+	for a != nil || b != nil {
+		select {
+		case v, ok := <-a:
+			if !ok {
+				a = nil
+				continue
+			}
+			got1 = append(got1, v)
+		case v, ok := <-b:
+			if !ok {
+				b = nil
+				continue
+			}
+			got2 = append(got2, v)
+		}
+	}
+
+	want := []string{"x", "y"}
+	if !reflect.DeepEqual(got1, want) || !reflect.DeepEqual(got2, want) {
+		t.Fatalf("got %v and %v", got1, got2)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	chans := make(chan (<-chan int), 2)
+	chans <- from(1, 2)
+	chans <- from(3)
+	close(chans)
+
+	if got := collect(t, Bridge(context.Background(), chans)); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestTakeRepeat(t *testing.T) {
+	base := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	got := collect(t, Take(ctx, Repeat(ctx, 1, 2), 5))
+	if !reflect.DeepEqual(got, []int{1, 2, 1, 2, 1}) {
+		t.Fatalf("got %v", got)
+	}
+
+	cancel()
+	checkGoroutines(t, base)
+
+	if got := collect(t, Repeat[int](context.Background())); len(got) != 0 {
+		t.Fatalf("Repeat() sent %v", got)
+	}
+}
+
+func TestFirstOf(t *testing.T) {
+	base := runtime.NumGoroutine()
+	ctx := context.Background()
+
+	v, err := FirstOf(ctx, make(chan int), from(7))
+	if err != nil || v != 7 {
+		t.Fatalf("got %v, %v", v, err)
+	}
+
+	if _, err := FirstOf(ctx, from[int](), from[int]()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("got %v, want ErrClosed", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := FirstOf(ctx, make(chan int)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+
+	checkGoroutines(t, base)
+}
+
+func TestAll(t *testing.T) {
+	a := make(chan int)
+	b := make(chan int)
+	done := All(context.Background(), a, b)
+
+	close(a)
+	select {
+	case <-done:
+		t.Fatal("All closed before every input closed")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	b <- 1
+	close(b)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("All did not close")
+	}
+}
+
+func TestDone(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	signal := make(chan struct{})
+	done := Done(context.Background(), signal, make(chan struct{}))
+	close(signal)
+	collect(t, done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done = Done(ctx, make(chan struct{}))
+	cancel()
+	collect(t, done)
+
+	checkGoroutines(t, base)
+}
+
+func TestAllCanceled(t *testing.T) {
+	base := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := All(ctx, make(chan int))
+	cancel()
+	collect(t, done)
+
+	checkGoroutines(t, base)
+}
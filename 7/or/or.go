@@ -0,0 +1,191 @@
+// Package or combines several done-channels into one that closes as soon as
+// any of them fires.
+//
+// A channel fires when it is closed or delivers a value; the values
+// themselves are discarded. Every variant releases all of its goroutines once
+// the result is closed, so abandoned inputs that never fire are the only
+// thing that can keep a goroutine alive, exactly as with a plain receive.
+// Any and the Source constructors avoid even that by stopping the remaining
+// sources once the combined channel fires.
+package or
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Strategy selects how the inputs are waited on.
+type Strategy int
+
+const (
+	// Auto picks a strategy by the number of inputs.
+	Auto Strategy = iota
+	// PerChannel runs one goroutine per input.
+	PerChannel
+	// Tree runs a balanced tree of goroutines, each waiting on a few inputs.
+	Tree
+	// Select waits with reflect.Select, one goroutine per batch of inputs.
+	Select
+)
+
+// selectBatch is the number of inputs one reflect.Select waits on; the last
+// of its 65536 cases watches the result so the batch exits when another fires.
+const selectBatch = 65535
+
+// treeFanIn is the number of inputs a leaf of the tree waits on.
+const treeFanIn = 4
+
+// Or returns a channel that is closed when any of channels fires, using the
+// strategy Choose picks for their number. With no inputs the result is nil and
+// never fires.
+func Or[T any](channels ...<-chan T) <-chan T {
+	return With(Auto, channels...)
+}
+
+// Choose returns the strategy Auto uses for n inputs. Per BenchmarkStrategies
+// a single tree leaf is cheapest while the inputs fit in it, and batched
+// reflect.Select beats goroutines for anything larger.
+func Choose(n int) Strategy {
+	if n <= treeFanIn {
+		return Tree
+	}
+
+	return Select
+}
+
+// With is Or with an explicit strategy.
+func With[T any](s Strategy, channels ...<-chan T) <-chan T {
+	if s == Auto {
+		s = Choose(len(channels))
+	}
+
+	switch s {
+	case PerChannel:
+		return perChannel(channels)
+	case Tree:
+		return Recursive(channels...)
+	}
+
+	return Reflect(channels...)
+}
+
+// perChannel is Or with one goroutine per input; each exits when the result
+// is closed.
+func perChannel[T any](channels []<-chan T) <-chan T {
+	switch len(channels) {
+	case 0:
+		return nil
+	case 1:
+		return channels[0]
+	}
+
+	out := make(chan T)
+	var once sync.Once
+
+	for _, ch := range channels {
+		go func() {
+			select {
+			case <-ch:
+				once.Do(func() {
+					close(out)
+				})
+			case <-out:
+			}
+		}()
+	}
+
+	return out
+}
+
+// Recursive is Or built as a balanced tree: leaves wait on up to four inputs
+// and every inner node on its two subtrees, so it runs about n/2 goroutines.
+func Recursive[T any](channels ...<-chan T) <-chan T {
+	switch len(channels) {
+	case 0:
+		return nil
+	case 1:
+		return channels[0]
+	}
+
+	out := make(chan T)
+
+	if len(channels) <= treeFanIn {
+		// Unused slots stay nil and never fire.
+		var c [treeFanIn]<-chan T
+		copy(c[:], channels)
+
+		go func() {
+			defer close(out)
+			select {
+			case <-c[0]:
+			case <-c[1]:
+			case <-c[2]:
+			case <-c[3]:
+			}
+		}()
+
+		return out
+	}
+
+	// out is passed down so each subtree exits as soon as this node fires.
+	mid := len(channels) / 2
+	left := Recursive(withStop(channels[:mid], out)...)
+	right := Recursive(withStop(channels[mid:], out)...)
+
+	go func() {
+		defer close(out)
+		select {
+		case <-left:
+		case <-right:
+		}
+	}()
+
+	return out
+}
+
+func withStop[T any](channels []<-chan T, stop <-chan T) []<-chan T {
+	c := make([]<-chan T, 0, len(channels)+1)
+	c = append(c, channels...)
+	return append(c, stop)
+}
+
+// Reflect is Or built on reflect.Select, one goroutine per batch of 65535
+// inputs.
+func Reflect[T any](channels ...<-chan T) <-chan T {
+	switch len(channels) {
+	case 0:
+		return nil
+	case 1:
+		return channels[0]
+	}
+
+	out := make(chan T)
+	var once sync.Once
+
+	for lo := 0; lo < len(channels); lo += selectBatch {
+		batch := channels[lo:min(lo+selectBatch, len(channels))]
+
+		cases := make([]reflect.SelectCase, len(batch)+1)
+		for i, ch := range batch {
+			cases[i] = recvCase(ch)
+		}
+		cases[len(batch)] = recvCase(out)
+
+		go func() {
+			if chosen, _, _ := reflect.Select(cases); chosen < len(batch) {
+				once.Do(func() {
+					close(out)
+				})
+			}
+		}()
+	}
+
+	return out
+}
+
+func recvCase[T any](ch <-chan T) reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(ch),
+	}
+}
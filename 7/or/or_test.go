@@ -0,0 +1,144 @@
+package or
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var variants = []struct {
+	name     string
+	strategy Strategy
+}{
+	{"PerChannel", PerChannel},
+	{"Tree", Tree},
+	{"Select", Select},
+	{"Auto", Auto},
+}
+
+func makeChannels(n int) ([]chan struct{}, []<-chan struct{}) {
+	chans := make([]chan struct{}, n)
+	recv := make([]<-chan struct{}, n)
+	for i := range chans {
+		chans[i] = make(chan struct{})
+		recv[i] = chans[i]
+	}
+
+	return chans, recv
+}
+
+func waitClosed(t *testing.T, ch <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("or-channel did not close")
+	}
+}
+
+// checkGoroutines fails unless the goroutine count falls back to base; the
+// exiting goroutines need a moment to be accounted.
+func checkGoroutines(t *testing.T, base int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d running, want %d", runtime.NumGoroutine(), base)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCloseReleasesGoroutines(t *testing.T) {
+	for _, v := range variants {
+		for _, n := range []int{2, 3, 4, 10, 100} {
+			for _, fire := range []int{0, n / 2, n - 1} {
+				t.Run(fmt.Sprintf("%s/n=%d/fire=%d", v.name, n, fire), func(t *testing.T) {
+					base := runtime.NumGoroutine()
+					chans, recv := makeChannels(n)
+
+					out := With(v.strategy, recv...)
+					close(chans[fire])
+					waitClosed(t, out)
+
+					checkGoroutines(t, base)
+				})
+			}
+		}
+	}
+}
+
+func TestValueFires(t *testing.T) {
+	for _, v := range variants {
+		t.Run(v.name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			chans, recv := makeChannels(5)
+
+			out := With(v.strategy, recv...)
+			chans[3] <- struct{}{}
+			waitClosed(t, out)
+
+			if _, ok := <-out; ok {
+				t.Fatal("or-channel delivered a value instead of closing")
+			}
+			checkGoroutines(t, base)
+		})
+	}
+}
+
+func TestNotClosedEarly(t *testing.T) {
+	for _, v := range variants {
+		t.Run(v.name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			chans, recv := makeChannels(7)
+
+			out := With(v.strategy, recv...)
+			select {
+			case <-out:
+				t.Fatal("or-channel closed before any input fired")
+			case <-time.After(10 * time.Millisecond):
+			}
+
+			close(chans[6])
+			waitClosed(t, out)
+			checkGoroutines(t, base)
+		})
+	}
+}
+
+func TestSingleAndEmpty(t *testing.T) {
+	for _, v := range variants {
+		t.Run(v.name, func(t *testing.T) {
+			if out := With[struct{}](v.strategy); out != nil {
+				t.Fatal("Or() should return nil")
+			}
+
+			ch := make(chan struct{})
+			if out := With(v.strategy, ch); out != (<-chan struct{})(ch) {
+				t.Fatal("Or(ch) should return ch")
+			}
+		})
+	}
+}
+
+func TestDoesNotModifyInput(t *testing.T) {
+	_, recv := makeChannels(8)
+	backing := make([]<-chan struct{}, 5, 8)
+	copy(backing, recv)
+	extra := backing[:8]
+	copy(extra[5:], recv[5:])
+
+	done := make(chan struct{})
+	close(done)
+	backing[0] = done
+	waitClosed(t, Recursive(backing...))
+
+	for i := 5; i < 8; i++ {
+		if extra[i] != recv[i] {
+			t.Fatalf("element %d beyond len was overwritten", i)
+		}
+	}
+}
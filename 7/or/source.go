@@ -0,0 +1,150 @@
+package or
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// Source starts a signal and returns a channel that is closed when it fires.
+// The source must release its resources and close the channel as soon as
+// stop is closed, fired or not.
+type Source func(stop <-chan struct{}) <-chan struct{}
+
+// Any starts sources and returns a channel that is closed when one of them
+// fires; at that point every other source is stopped. With no sources the
+// result is nil and never fires.
+func Any(sources ...Source) <-chan struct{} {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	stop := make(chan struct{})
+	channels := make([]<-chan struct{}, len(sources))
+	for i, src := range sources {
+		channels[i] = src(stop)
+	}
+
+	fired := Or(channels...)
+	go func() {
+		<-fired
+		close(stop)
+	}()
+
+	return fired
+}
+
+// After fires once d has elapsed.
+func After(d time.Duration) Source {
+	return func(stop <-chan struct{}) <-chan struct{} {
+		out := make(chan struct{})
+		timer := time.NewTimer(d)
+
+		go func() {
+			defer close(out)
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+			case <-stop:
+			}
+		}()
+
+		return out
+	}
+}
+
+// At fires at the deadline t; a deadline in the past fires at once.
+func At(t time.Time) Source {
+	return func(stop <-chan struct{}) <-chan struct{} {
+		return After(time.Until(t))(stop)
+	}
+}
+
+// OnSignal fires when the process receives one of sigs. Delivery of sigs is
+// restored to its previous state once the source stops.
+func OnSignal(sigs ...os.Signal) Source {
+	return func(stop <-chan struct{}) <-chan struct{} {
+		out := make(chan struct{})
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, sigs...)
+
+		go func() {
+			defer close(out)
+			defer signal.Stop(c)
+
+			select {
+			case <-c:
+			case <-stop:
+			}
+		}()
+
+		return out
+	}
+}
+
+// OnContext fires when ctx is done.
+func OnContext(ctx context.Context) Source {
+	return func(stop <-chan struct{}) <-chan struct{} {
+		out := make(chan struct{})
+
+		go func() {
+			defer close(out)
+
+			select {
+			case <-ctx.Done():
+			case <-stop:
+			}
+		}()
+
+		return out
+	}
+}
+
+// OnFileChange fires when the file at path is created, removed, or changes
+// size or modification time. The file is polled every interval.
+func OnFileChange(path string, interval time.Duration) Source {
+	return func(stop <-chan struct{}) <-chan struct{} {
+		out := make(chan struct{})
+		initial := statFile(path)
+		ticker := time.NewTicker(interval)
+
+		go func() {
+			defer close(out)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if statFile(path) != initial {
+						return
+					}
+				case <-stop:
+					return
+				}
+			}
+		}()
+
+		return out
+	}
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+
+	return fileState{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+}
@@ -0,0 +1,78 @@
+package or
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// baseGoroutines returns the goroutine count after the os/signal watcher,
+// which lives for the rest of the process once started, is running.
+func baseGoroutines() int {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR2)
+	signal.Stop(c)
+
+	return runtime.NumGoroutine()
+}
+
+func TestSourcesFire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watched")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		source Source
+		fire   func()
+	}{
+		{"After", After(time.Millisecond), func() {}},
+		{"At", At(time.Now().Add(-time.Second)), func() {}},
+		{"OnContext", OnContext(ctx), cancel},
+		{"OnSignal", OnSignal(syscall.SIGUSR1), func() {
+			syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		}},
+		{"OnFileChange", OnFileChange(path, time.Millisecond), func() {
+			os.WriteFile(path, []byte("changed"), 0o644)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := baseGoroutines()
+
+			out := Any(tt.source, After(time.Hour))
+			tt.fire()
+			waitClosed(t, out)
+
+			checkGoroutines(t, base)
+		})
+	}
+}
+
+func TestAnyStopsPendingSources(t *testing.T) {
+	base := baseGoroutines()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	out := Any(
+		After(time.Hour),
+		At(time.Now().Add(time.Hour)),
+		OnSignal(syscall.SIGUSR1),
+		OnContext(ctx),
+		OnFileChange(filepath.Join(t.TempDir(), "missing"), time.Millisecond),
+		After(10*time.Millisecond),
+	)
+	waitClosed(t, out)
+
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Fatalf("fired after %v, before any source", elapsed)
+	}
+	checkGoroutines(t, base)
+}
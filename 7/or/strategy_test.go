@@ -0,0 +1,102 @@
+package or
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		n    int
+		want Strategy
+	}{
+		{2, Tree},
+		{treeFanIn, Tree},
+		{treeFanIn + 1, Select},
+		{100000, Select},
+	}
+
+	for _, tt := range tests {
+		if got := Choose(tt.n); got != tt.want {
+			t.Errorf("Choose(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSelectBatches(t *testing.T) {
+	// Fire the last input so that it sits in the second batch.
+	for _, fire := range []int{0, selectBatch + 10} {
+		base := runtime.NumGoroutine()
+		chans, recv := makeChannels(selectBatch + 11)
+
+		out := With(Select, recv...)
+		close(chans[fire])
+		waitClosed(t, out)
+
+		checkGoroutines(t, base)
+	}
+}
+
+// BenchmarkStrategies measures building the combined channel and waiting for
+// it after one input closes; its results drive Choose.
+func BenchmarkStrategies(b *testing.B) {
+	strategies := []struct {
+		name     string
+		strategy Strategy
+	}{
+		{"PerChannel", PerChannel},
+		{"Tree", Tree},
+		{"Select", Select},
+	}
+
+	for _, n := range []int{2, 4, 10, 100, 1000, 10000, 100000} {
+		for _, s := range strategies {
+			b.Run(fmt.Sprintf("n=%d/%s", n, s.name), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					chans, recv := makeChannels(n)
+					out := With(s.strategy, recv...)
+					close(chans[n/2])
+					<-out
+				}
+			})
+		}
+	}
+}
+
+// FuzzCloseOrder closes the inputs in a random order and checks that the
+// result closes promptly after the first one and that nothing leaks.
+func FuzzCloseOrder(f *testing.F) {
+	f.Add(uint16(2), uint8(0), int64(1))
+	f.Add(uint16(100), uint8(1), int64(2))
+	f.Add(uint16(1000), uint8(2), int64(3))
+	f.Add(uint16(65535), uint8(3), int64(4))
+
+	f.Fuzz(func(t *testing.T, n uint16, strategy uint8, seed int64) {
+		if n < 2 {
+			t.Skip()
+		}
+		s := Strategy(strategy % 4)
+
+		base := runtime.NumGoroutine()
+		chans, recv := makeChannels(int(n))
+		out := With(s, recv...)
+
+		rng := rand.New(rand.NewSource(seed))
+		order := rng.Perm(int(n))
+
+		start := time.Now()
+		close(chans[order[0]])
+		waitClosed(t, out)
+		if latency := time.Since(start); latency > 500*time.Millisecond {
+			t.Fatalf("%v with %d inputs closed after %v", s, n, latency)
+		}
+
+		for _, i := range order[1:] {
+			close(chans[i])
+		}
+		checkGoroutines(t, base)
+	})
+}
@@ -1,148 +1,244 @@
-package main
-
-import (
-	"bufio"
-	"errors"
-	"fmt"
-	"log"
-	"os"
-	"strconv"
-	"strings"
-
-	ps "github.com/mitchellh/go-ps"
-)
-
-func main() {
-	reader := bufio.NewReader(os.Stdin)
-
-	for {
-		fmt.Print("customShell> ")
-
-		lineBytes, _, err := reader.ReadLine()
-		if err != nil {
-			log.Println(err)
-		}
-
-		line := string(lineBytes)
-
-		if line == "" {
-			continue
-		}
-
-		if line == `\q` {
-			break
-		}
-
-		pipes := strings.Split(line, "|")
-
-		for _, pipe := range pipes {
-			pipe = strings.TrimSpace(pipe)
-			err = Exec(pipe)
-			if err != nil {
-				log.Println(err)
-			}
-		}
-
-	}
-}
-
-func Exec(line string) error {
-	split := strings.Split(line, " ")
-
-	cmd := split[0]
-	args := split[1:]
-
-	switch cmd {
-	case "cd":
-		err := ChangeDir(args)
-		if err != nil {
-			return err
-		}
-
-	case "pwd":
-		err := PrintWorkDir()
-		if err != nil {
-			log.Println(err)
-		}
-
-	case "echo":
-		Echo(args)
-
-	case "ps":
-		err := PrintProcs()
-		if err != nil {
-			log.Println(err)
-		}
-
-	case "kill":
-		KillProc(args)
-	}
-
-	return nil
-}
-
-func ChangeDir(args []string) error {
-	if len(args) > 1 {
-		return errors.New("cd: too many arguments")
-	}
-
-	if len(args) == 0 {
-		return errors.New("cd: not enough arguments")
-	}
-
-	path := args[0]
-
-	return os.Chdir(path)
-}
-
-func PrintWorkDir() error {
-	dir, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	fmt.Println(dir)
-	return nil
-}
-
-func Echo(args []string) {
-	str := strings.Join(args, " ")
-	fmt.Println(str)
-}
-
-func PrintProcs() error {
-	procs, err := ps.Processes()
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("PID\tPPID\tExecutable")
-
-	for _, p := range procs {
-		fmt.Printf("%d\t%d\t%s\n", p.Pid(), p.PPid(), p.Executable())
-	}
-
-	return nil
-}
-
-func KillProc(args []string) error {
-	if len(args) > 1 {
-		return errors.New("kill: too many arguments")
-	}
-
-	if len(args) == 0 {
-		return errors.New("kill: not enough arguments")
-	}
-
-	pid, err := strconv.Atoi(args[0])
-	if err != nil {
-		return err
-	}
-
-	proc, err := os.FindProcess(pid)
-	if err != nil {
-		return err
-	}
-
-	return proc.Kill()
-}
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	ps "github.com/mitchellh/go-ps"
+)
+
+func main() {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Print("customShell> ")
+
+		lineBytes, _, err := reader.ReadLine()
+		if err == io.EOF {
+			fmt.Println()
+			break
+		}
+		if err != nil {
+			log.Println(err)
+		}
+
+		line := string(lineBytes)
+
+		if line == "" {
+			continue
+		}
+
+		if line == `\q` {
+			break
+		}
+
+		pipeline, err := Parse(line, Lookup)
+		if err != nil {
+			log.Println(err)
+			status = 2
+			continue
+		}
+		if len(pipeline) == 0 {
+			continue
+		}
+
+		status = RunPipeline(pipeline)
+	}
+}
+
+// status is the exit status of the last pipeline, available as $?.
+var status int
+
+// pipefail makes a pipeline fail with the status of its last failing stage
+// instead of the status of its last stage.
+var pipefail bool
+
+// Command is one stage of a pipeline.
+type Command struct {
+	Name string
+	Args []string
+}
+
+// Builtin runs in the shell process and reports failure with an error, which
+// gives status 1.
+type Builtin func(args []string, stdin io.Reader, stdout io.Writer) error
+
+var builtins map[string]Builtin
+
+func init() {
+	builtins = map[string]Builtin{
+		"cd": func(args []string, _ io.Reader, _ io.Writer) error {
+			return ChangeDir(args)
+		},
+		"pwd": func(_ []string, _ io.Reader, stdout io.Writer) error {
+			return PrintWorkDir(stdout)
+		},
+		"echo": func(args []string, _ io.Reader, stdout io.Writer) error {
+			return Echo(stdout, args)
+		},
+		"ps": func(_ []string, _ io.Reader, stdout io.Writer) error {
+			return PrintProcs(stdout)
+		},
+		"kill": func(args []string, _ io.Reader, _ io.Writer) error {
+			return KillProc(args)
+		},
+		"set": func(args []string, _ io.Reader, _ io.Writer) error {
+			return SetOption(args)
+		},
+		"export": func(args []string, _ io.Reader, stdout io.Writer) error {
+			return Export(stdout, args)
+		},
+		"unset": func(args []string, _ io.Reader, _ io.Writer) error {
+			return Unset(args)
+		},
+		// A stage whose words all expanded to nothing.
+		"": func([]string, io.Reader, io.Writer) error {
+			return nil
+		},
+	}
+}
+
+// Lookup returns the value of a variable: $? or one from the environment.
+func Lookup(name string) string {
+	if name == "?" {
+		return strconv.Itoa(status)
+	}
+
+	return os.Getenv(name)
+}
+
+func ChangeDir(args []string) error {
+	if len(args) > 1 {
+		return errors.New("cd: too many arguments")
+	}
+
+	if len(args) == 0 {
+		return errors.New("cd: not enough arguments")
+	}
+
+	path := args[0]
+
+	return os.Chdir(path)
+}
+
+func PrintWorkDir(w io.Writer) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, dir)
+	return err
+}
+
+func Echo(w io.Writer, args []string) error {
+	str := strings.Join(args, " ")
+	_, err := fmt.Fprintln(w, str)
+	return err
+}
+
+func PrintProcs(w io.Writer) error {
+	procs, err := ps.Processes()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "PID\tPPID\tExecutable")
+
+	for _, p := range procs {
+		fmt.Fprintf(bw, "%d\t%d\t%s\n", p.Pid(), p.PPid(), p.Executable())
+	}
+
+	return bw.Flush()
+}
+
+func KillProc(args []string) error {
+	if len(args) > 1 {
+		return errors.New("kill: too many arguments")
+	}
+
+	if len(args) == 0 {
+		return errors.New("kill: not enough arguments")
+	}
+
+	pid, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return proc.Kill()
+}
+
+func SetOption(args []string) error {
+	if len(args) != 2 || args[1] != "pipefail" {
+		return errors.New("set: usage: set -o|+o pipefail")
+	}
+
+	switch args[0] {
+	case "-o":
+		pipefail = true
+	case "+o":
+		pipefail = false
+	default:
+		return errors.New("set: usage: set -o|+o pipefail")
+	}
+
+	return nil
+}
+
+// Export sets NAME=VALUE in the environment passed to commands; a bare NAME
+// is accepted and left as it is. With no arguments it prints the environment.
+func Export(w io.Writer, args []string) error {
+	if len(args) == 0 {
+		env := os.Environ()
+		sort.Strings(env)
+
+		bw := bufio.NewWriter(w)
+		for _, kv := range env {
+			name, value, _ := strings.Cut(kv, "=")
+			fmt.Fprintf(bw, "export %s=%s\n", name, strconv.Quote(value))
+		}
+		return bw.Flush()
+	}
+
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !validName(name) {
+			return fmt.Errorf("export: %q: not a valid identifier", arg)
+		}
+		if !ok {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Unset(args []string) error {
+	for _, name := range args {
+		if !validName(name) {
+			return fmt.Errorf("unset: %q: not a valid identifier", name)
+		}
+		if err := os.Unsetenv(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+/*
+	Разбор строки идёт в два шага: лексер режет её на слова и `|`, парсер
+	собирает из слов стадии конвейера и раскрывает каждое слово.
+
+		'...'        всё буквально
+		"..."        буквально, кроме $VAR, ${VAR} и \$ \" \\ \`
+		\c           символ c буквально
+		$VAR ${VAR}  значение переменной, $? — статус последней команды
+		~ ~/path     $HOME в начале слова
+		* ? [...]    файлы по filepath.Glob; без совпадений слово остаётся как есть
+
+	Значения переменных не делятся на слова и не раскрываются как шаблоны.
+	Слово, которое раскрылось в пустую строку без кавычек, пропадает.
+*/
+
+type partKind int
+
+const (
+	partLiteral partKind = iota
+	partQuoted
+	partVar
+	partTilde
+)
+
+// part is a piece of a word; only unquoted literals take part in globbing.
+type part struct {
+	kind   partKind
+	text   string
+	quoted bool
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenPipe
+)
+
+type token struct {
+	kind  tokenKind
+	parts []part
+}
+
+type lexer struct {
+	input  string
+	pos    int
+	tokens []token
+	word   []part
+	inWord bool
+}
+
+func lex(input string) ([]token, error) {
+	l := &lexer{input: input}
+
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+
+		switch {
+		case c == ' ' || c == '\t':
+			l.endWord()
+			l.pos++
+		case c == '|':
+			l.endWord()
+			l.tokens = append(l.tokens, token{kind: tokenPipe})
+			l.pos++
+		case c == '\'':
+			if err := l.single(); err != nil {
+				return nil, err
+			}
+		case c == '"':
+			if err := l.double(); err != nil {
+				return nil, err
+			}
+		case c == '\\':
+			if l.pos+1 == len(l.input) {
+				return nil, errors.New("unexpected end of line after \\")
+			}
+			l.add(part{kind: partQuoted, text: l.input[l.pos+1 : l.pos+2]})
+			l.pos += 2
+		case c == '$':
+			if err := l.variable(false); err != nil {
+				return nil, err
+			}
+		case c == '~' && !l.inWord && l.tildeEnds(l.pos+1):
+			l.add(part{kind: partTilde})
+			l.pos++
+		default:
+			start := l.pos
+			for l.pos < len(l.input) && !strings.ContainsRune(" \t|'\"\\$", rune(l.input[l.pos])) {
+				l.pos++
+			}
+			l.add(part{kind: partLiteral, text: l.input[start:l.pos]})
+		}
+	}
+
+	l.endWord()
+	return l.tokens, nil
+}
+
+func (l *lexer) add(p part) {
+	l.word = append(l.word, p)
+	l.inWord = true
+}
+
+func (l *lexer) endWord() {
+	if l.inWord {
+		l.tokens = append(l.tokens, token{kind: tokenWord, parts: l.word})
+	}
+	l.word = nil
+	l.inWord = false
+}
+
+func (l *lexer) tildeEnds(i int) bool {
+	return i == len(l.input) || strings.ContainsRune("/ \t|", rune(l.input[i]))
+}
+
+func (l *lexer) single() error {
+	end := strings.IndexByte(l.input[l.pos+1:], '\'')
+	if end < 0 {
+		return errors.New("unterminated '")
+	}
+
+	l.add(part{kind: partQuoted, text: l.input[l.pos+1 : l.pos+1+end]})
+	l.pos += end + 2
+	return nil
+}
+
+func (l *lexer) double() error {
+	// An empty "" still makes a word.
+	l.add(part{kind: partQuoted})
+	l.pos++
+
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			l.add(part{kind: partQuoted, text: b.String()})
+			b.Reset()
+		}
+	}
+
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+
+		switch {
+		case c == '"':
+			flush()
+			l.pos++
+			return nil
+		case c == '\\' && l.pos+1 < len(l.input) && strings.IndexByte("$\"\\`", l.input[l.pos+1]) >= 0:
+			b.WriteByte(l.input[l.pos+1])
+			l.pos += 2
+		case c == '$':
+			flush()
+			if err := l.variable(true); err != nil {
+				return err
+			}
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return errors.New(`unterminated "`)
+}
+
+// variable reads $NAME, ${NAME} or $? at l.pos; a $ not followed by a name
+// is literal.
+func (l *lexer) variable(quoted bool) error {
+	rest := l.input[l.pos+1:]
+	literal := partLiteral
+	if quoted {
+		literal = partQuoted
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "{"):
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return errors.New("unterminated ${")
+		}
+		name := rest[1:end]
+		if name != "?" && !validName(name) {
+			return fmt.Errorf("${%s}: bad substitution", name)
+		}
+		l.add(part{kind: partVar, text: name, quoted: quoted})
+		l.pos += end + 2
+
+	case strings.HasPrefix(rest, "?"):
+		l.add(part{kind: partVar, text: "?", quoted: quoted})
+		l.pos += 2
+
+	case len(rest) > 0 && isNameStart(rest[0]):
+		n := 1
+		for n < len(rest) && isNameChar(rest[n]) {
+			n++
+		}
+		l.add(part{kind: partVar, text: rest[:n], quoted: quoted})
+		l.pos += n + 1
+
+	default:
+		l.add(part{kind: literal, text: "$"})
+		l.pos++
+	}
+
+	return nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || '0' <= c && c <= '9'
+}
+
+func validName(name string) bool {
+	if name == "" || !isNameStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isNameChar(name[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Parse splits line into the stages of a pipeline and expands their words,
+// looking variables up with lookup. An empty line gives no stages.
+func Parse(line string, lookup func(string) string) ([]Command, error) {
+	tokens, err := lex(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	var pipeline []Command
+	var words []string
+	empty := true
+
+	for _, tok := range tokens {
+		if tok.kind == tokenPipe {
+			if empty {
+				return nil, errors.New("syntax error near unexpected token `|'")
+			}
+			pipeline = append(pipeline, newCommand(words))
+			words, empty = nil, true
+			continue
+		}
+
+		words = append(words, expand(tok.parts, lookup)...)
+		empty = false
+	}
+
+	if empty {
+		return nil, errors.New("syntax error: unexpected end of line after `|'")
+	}
+
+	return append(pipeline, newCommand(words)), nil
+}
+
+// newCommand builds a stage; words that all expanded to nothing give a
+// command with an empty Name, which does nothing.
+func newCommand(words []string) Command {
+	if len(words) == 0 {
+		return Command{}
+	}
+
+	return Command{
+		Name: words[0],
+		Args: words[1:],
+	}
+}
+
+// expand turns the parts of a word into zero or more arguments.
+func expand(parts []part, lookup func(string) string) []string {
+	var value, pattern strings.Builder
+	glob, quoted := false, false
+
+	for _, p := range parts {
+		var s string
+
+		switch p.kind {
+		case partLiteral:
+			value.WriteString(p.text)
+			pattern.WriteString(p.text)
+			glob = glob || strings.ContainsAny(p.text, "*?[")
+			continue
+		case partQuoted:
+			s = p.text
+			quoted = true
+		case partVar:
+			s = lookup(p.text)
+			quoted = quoted || p.quoted
+		case partTilde:
+			s = lookup("HOME")
+		}
+
+		value.WriteString(s)
+		pattern.WriteString(escapeGlob(s))
+	}
+
+	if glob {
+		if matches, err := filepath.Glob(pattern.String()); err == nil && len(matches) > 0 {
+			return matches
+		}
+	}
+
+	if value.Len() == 0 && !quoted {
+		return nil
+	}
+
+	return []string{value.String()}
+}
+
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fakeLookup(name string) string {
+	return map[string]string{
+		"HOME":  "/home/me",
+		"USER":  "me",
+		"?":     "3",
+		"SPACE": "a  b",
+		"STAR":  "*",
+	}[name]
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []Command
+	}{
+		{"empty", "", nil},
+		{"blank", "  \t ", nil},
+		{"words", "ls -l /tmp", []Command{{"ls", []string{"-l", "/tmp"}}}},
+		{"repeated spaces", "  echo   a \t b  ", []Command{{"echo", []string{"a", "b"}}}},
+		{"single quotes", `echo 'a  b' '$USER' 'x"y'`, []Command{{"echo", []string{"a  b", "$USER", `x"y`}}}},
+		{"double quotes", `echo "a  b" "$USER" "x'y"`, []Command{{"echo", []string{"a  b", "me", "x'y"}}}},
+		{"empty quotes", `echo "" ''`, []Command{{"echo", []string{"", ""}}}},
+		{"adjacent quotes", `echo a'b'"c"`, []Command{{"echo", []string{"abc"}}}},
+		{"backslash", `echo a\ b \$USER \'`, []Command{{"echo", []string{"a b", "$USER", "'"}}}},
+		{"backslash in double quotes", `echo "\$ \" \\ \n"`, []Command{{"echo", []string{`$ " \ \n`}}}},
+		{"variables", "echo $USER ${USER}x $?", []Command{{"echo", []string{"me", "mex", "3"}}}},
+		{"no field splitting", "echo $SPACE", []Command{{"echo", []string{"a  b"}}}},
+		{"unset variable dropped", "echo $NOPE end", []Command{{"echo", []string{"end"}}}},
+		{"quoted unset variable kept", `echo "$NOPE" end`, []Command{{"echo", []string{"", "end"}}}},
+		{"lone dollar", "echo $ a$ $1", []Command{{"echo", []string{"$", "a$", "$1"}}}},
+		{"tilde", "echo ~ ~/x a~ '~' ~me", []Command{{"echo", []string{"/home/me", "/home/me/x", "a~", "~", "~me"}}}},
+		{"glob without match", "echo /nonexistent/*.go", []Command{{"echo", []string{"/nonexistent/*.go"}}}},
+		{"variable not globbed", "echo $STAR", []Command{{"echo", []string{"*"}}}},
+		{"pipeline", "ps | grep go|wc -l", []Command{
+			{"ps", nil},
+			{"grep", []string{"go"}},
+			{"wc", []string{"-l"}},
+		}},
+		{"pipe in quotes", `echo "a|b" 'c|d' e\|f`, []Command{{"echo", []string{"a|b", "c|d", "e|f"}}}},
+		{"expands to nothing", "$NOPE", []Command{{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.line, fakeLookup)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range got {
+				if len(got[i].Args) == 0 {
+					got[i].Args = nil
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`echo 'a`, "unterminated '"},
+		{`echo "a`, `unterminated "`},
+		{`echo a\`, "end of line"},
+		{"echo ${USER", "unterminated ${"},
+		{"echo ${1x}", "bad substitution"},
+		{"| cat", "unexpected token"},
+		{"ls || cat", "unexpected token"},
+		{"ls |", "end of line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := Parse(tt.line, fakeLookup)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.txt", "[x].go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.go", []string{"[x].go", "a.go", "b.go"}},
+		{"?.txt", []string{"c.txt"}},
+		{"[ab].go", []string{"a.go", "b.go"}},
+		{`\[x\].go`, []string{"[x].go"}},
+		{"'*.go'", []string{"*.go"}},
+		{`"*".go`, []string{"*.go"}},
+		{"*.md", []string{"*.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := Parse("ls "+dir+"/"+tt.pattern, fakeLookup)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rel []string
+			for _, arg := range got[0].Args {
+				rel = append(rel, strings.TrimPrefix(arg, dir+"/"))
+			}
+			if !reflect.DeepEqual(rel, tt.want) {
+				t.Fatalf("got %q, want %q", rel, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportUnset(t *testing.T) {
+	const name = "SHELL_TEST_VAR"
+	t.Setenv(name, "")
+
+	tests := []struct {
+		builtin string
+		args    []string
+		want    string
+		set     bool
+		wantErr bool
+	}{
+		{"export", []string{name + "=a b"}, "a b", true, false},
+		{"export", []string{name + "=x=y"}, "x=y", true, false},
+		{"export", []string{name}, "x=y", true, false},
+		{"export", []string{"1BAD=1"}, "x=y", true, true},
+		{"unset", []string{name}, "", false, false},
+		{"unset", []string{"BAD-NAME"}, "", false, true},
+		{"export", []string{name + "="}, "", true, false},
+	}
+
+	for _, tt := range tests {
+		err := builtins[tt.builtin](tt.args, nil, nil)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s %q: error %v", tt.builtin, tt.args, err)
+		}
+
+		got, set := os.LookupEnv(name)
+		if got != tt.want || set != tt.set {
+			t.Fatalf("%s %q: got %q (set %v), want %q (set %v)", tt.builtin, tt.args, got, set, tt.want, tt.set)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// RunPipeline runs all stages at once, each stage's stdout connected to the
+// next one's stdin, and returns the status of the last stage, or with
+// pipefail of the last stage that failed.
+func RunPipeline(pipeline []Command) int {
+	// Ctrl-C goes to the whole foreground process group; the shell must
+	// survive it while the commands handle or die from it.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	waits := make([]func() int, len(pipeline))
+	stdin := os.Stdin
+
+	for i, cmd := range pipeline {
+		stdout := os.Stdout
+		var next *os.File
+
+		if i < len(pipeline)-1 {
+			r, w, err := os.Pipe()
+			if err != nil {
+				log.Println(err)
+				closePipe(stdin)
+				waits = waits[:i]
+				break
+			}
+			stdout, next = w, r
+		}
+
+		waits[i] = start(cmd, stdin, stdout)
+		stdin = next
+	}
+
+	status := 0
+	for _, wait := range waits {
+		code := wait()
+		if !pipefail || code != 0 {
+			status = code
+		}
+	}
+
+	return status
+}
+
+// start launches one stage and returns a function that waits for it. The
+// stage owns its pipe ends and closes them when it is done, so that readers
+// see EOF and writers a broken pipe as soon as it exits.
+func start(cmd Command, stdin, stdout *os.File) func() int {
+	if builtin, ok := builtins[cmd.Name]; ok {
+		done := make(chan int, 1)
+
+		go func() {
+			err := builtin(cmd.Args, stdin, stdout)
+
+			// The pipe ends are closed before the status is reported, so
+			// the stage is over once its wait returns.
+			closePipe(stdin)
+			closePipe(stdout)
+
+			done <- builtinStatus(err)
+		}()
+
+		return func() int {
+			return <-done
+		}
+	}
+
+	// A child process holds its own copies of the descriptors.
+	defer closePipe(stdin)
+	defer closePipe(stdout)
+
+	c, code := command(cmd)
+	if c == nil {
+		return func() int {
+			return code
+		}
+	}
+
+	c.Stdin = stdin
+	c.Stdout = stdout
+	c.Stderr = os.Stderr
+
+	if err := c.Start(); err != nil {
+		log.Println(err)
+		return func() int {
+			return 126
+		}
+	}
+
+	return func() int {
+		return exitStatus(c.Wait())
+	}
+}
+
+// command resolves cmd through $PATH. As in sh, a command that cannot be
+// found gives status 127 and one that cannot be run 126.
+func command(cmd Command) (*exec.Cmd, int) {
+	path, err := exec.LookPath(cmd.Name)
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		fmt.Fprintf(os.Stderr, "%s: command not found\n", cmd.Name)
+		return nil, 127
+	case errors.Is(err, fs.ErrNotExist):
+		// A name with a slash is not searched for in $PATH.
+		fmt.Fprintf(os.Stderr, "%s: No such file or directory\n", cmd.Name)
+		return nil, 127
+	case errors.Is(err, fs.ErrPermission):
+		fmt.Fprintf(os.Stderr, "%s: Permission denied\n", cmd.Name)
+		return nil, 126
+	case err != nil:
+		log.Println(err)
+		return nil, 126
+	}
+
+	c := exec.Command(path, cmd.Args...)
+	c.Args[0] = cmd.Name
+	return c, 0
+}
+
+// closePipe closes f unless it is the shell's own stdin or stdout.
+func closePipe(f *os.File) {
+	if f != nil && f != os.Stdin && f != os.Stdout {
+		f.Close()
+	}
+}
+
+// builtinStatus converts the result of a builtin to a status. Like a process
+// killed by SIGPIPE, a builtin whose reader went away fails quietly.
+func builtinStatus(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, syscall.EPIPE):
+		return 128 + int(syscall.SIGPIPE)
+	}
+
+	log.Println(err)
+	return 1
+}
+
+// exitStatus converts the result of Wait to a status; a command killed by a
+// signal gives 128+N.
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		log.Println(err)
+		return 1
+	}
+
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+
+	return exitErr.ExitCode()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCommandStatus(t *testing.T) {
+	noexec := filepath.Join(t.TempDir(), "noexec")
+	if err := os.WriteFile(noexec, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		found  bool
+		status int
+	}{
+		{"sh", true, 0},
+		{"no-such-command-here", false, 127},
+		{"./no-such-command-here", false, 127},
+		{"/no/such/dir/cmd", false, 127},
+		{noexec, false, 126},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, status := command(Command{Name: tt.name})
+			if (c != nil) != tt.found || status != tt.status {
+				t.Fatalf("got cmd %v, status %d; want found %v, status %d", c != nil, status, tt.found, tt.status)
+			}
+		})
+	}
+}
+
+// runLine runs line as a pipeline with stdin at EOF and returns what it wrote
+// to stdout and its status.
+func runLine(t *testing.T, line string) (string, int) {
+	t.Helper()
+
+	pipeline, err := Parse(line, os.Getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdin, stdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = devNull, w
+	defer func() {
+		os.Stdin, os.Stdout = stdin, stdout
+		devNull.Close()
+	}()
+
+	output := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		r.Close()
+		output <- string(b)
+	}()
+
+	done := make(chan int)
+	go func() {
+		done <- RunPipeline(pipeline)
+	}()
+
+	var status int
+	select {
+	case status = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%q did not finish", line)
+	}
+	w.Close()
+
+	return <-output, status
+}
+
+func TestRunPipeline(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line     string
+		pipefail bool
+		want     string
+		status   int
+	}{
+		{"echo hi | tr a-z A-Z", false, "HI\n", 0},
+		{"yes | head -n 2", false, "y\ny\n", 0},
+		{"printf 'b\\na\\n' | sort | head -n 1", false, "a\n", 0},
+		{"echo a b c | tr ' ' '\\n' | wc -l | tr -d ' '", false, "3\n", 0},
+		{"false | true", false, "", 0},
+		{"false | true", true, "", 1},
+		{"true | false", false, "", 1},
+		{"sh -c 'exit 3' | sh -c 'exit 4' | true", true, "", 4},
+		{"sh -c 'exit 3' | true | true", true, "", 3},
+		{"printf ignored | echo after", false, "after\n", 0},
+		{"sh -c 'echo x' | pwd | cat", false, wd + "\n", 0},
+		{"no-such-command-here | cat", false, "", 0},
+		{"no-such-command-here | cat", true, "", 127},
+		{"echo a | no-such-command-here", false, "", 127},
+		{"echo a | no-such-command-here", true, "", 127},
+		{"yes | head -n 1", true, "y\n", 141},
+		{"cd | cat", false, "", 0},
+		{"cd", false, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			pipefail = tt.pipefail
+			defer func() {
+				pipefail = false
+			}()
+
+			got, status := runLine(t, tt.line)
+			if got != tt.want || status != tt.status {
+				t.Fatalf("got %q, status %d; want %q, status %d", got, status, tt.want, tt.status)
+			}
+		})
+	}
+}